@@ -1,37 +1,47 @@
 package apispec
 
-import "github.com/go-playground/validator/v10"
-
 type AppConfig struct {
+	// Version は設定ファイルの形式のバージョン
+	// LatestVersion を指定する。古い形式の設定ファイルは読み込む際に LatestVersion の形式に移行される
+	Version string `json:"version" yaml:"version" validate:"required,version"`
 	// AppName はアプリケーションの名前
 	AppName string `json:"app_name" yaml:"app_name" validate:"required"`
 	// Build はアプリケーションのビルド設定
 	Build BuildConfig `json:"build" yaml:"build" validate:"required"`
 	// Releases はアプリケーションのリリース設定
-	Releases []ReleaseConfig `json:"releases" yaml:"releases" validate:"required"`
+	Releases []ReleaseConfig `json:"releases" yaml:"releases" validate:"required,dive"`
 	// Service はアプリケーションのサービス設定
 	Service ServiceConfig `json:"service" yaml:"service" validate:"required"`
+	// Workers はHTTPリクエストを受け付けないバックグラウンドプロセスの設定
+	Workers []WorkerConfig `json:"workers,omitempty" yaml:"workers,omitempty" validate:"omitempty,dive"`
+	// Jobs は定期的に実行するジョブの設定
+	Jobs []CronJobConfig `json:"jobs,omitempty" yaml:"jobs,omitempty" validate:"omitempty,dive"`
 	// Stages はアプリケーションのステージ設定
-	// 何も定義されていない場合は、デフォルトで `production` ステージが作成される
-	Stages []StageConfig `json:"stages,omitempty" yaml:"stages,omitempty"`
+	// 何も定義されていない場合は、デフォルトで `production` ステージが作成される (ApplyDefaults を参照)
+	Stages []StageConfig `json:"stages,omitempty" yaml:"stages,omitempty" validate:"omitempty,dive"`
 }
 
 // Validate は AppConfig のバリデーションを行う
+// エラーがある場合は ValidationErrors を返す
 func (c *AppConfig) Validate() error {
-	v := validator.New()
-	return v.Struct(c)
+	return validateStruct(c)
 }
 
 // BuildConfig はアプリケーションのビルド設定を表す
+// Image と Dockerfile のどちらか一方のみを指定する
 type BuildConfig struct {
 	// Image はイメージビルドを行わず、既存のイメージを使用する場合に指定する
-	Image string `json:"image" yaml:"image"`
+	Image string `json:"image" yaml:"image" validate:"required_without=Dockerfile,excluded_with=Dockerfile"`
 	// Dockerfile はDockerイメージをビルドするためのDockerfileのパス
 	Dockerfile string `json:"dockerfile" yaml:"dockerfile"`
 	// DockerContextはDockerイメージをビルドするためのコンテキストのパス
-	DockerContext string `json:"docker_context" yaml:"docker_context"`
+	// 省略した場合は Dockerfile のあるディレクトリが使用される
+	// Image を指定した場合は指定できない
+	DockerContext string `json:"docker_context" yaml:"docker_context" validate:"excluded_with=Image"`
 	// BuildArgs はDockerビルド時に使用するビルド引数
-	BuildArgs map[string]string `json:"build_args" yaml:"build_args"`
+	// Image を指定した場合は指定できない (空のマップは指定していないものとして扱う)
+	// ビルド引数はイメージに残るため、シークレットへの参照 (`secret://`) は指定できない
+	BuildArgs map[string]string `json:"build_args" yaml:"build_args" validate:"dive,nosecret"`
 }
 
 // ReleaseConfig はアプリケーションのリリース設定を表す
@@ -42,14 +52,17 @@ type ReleaseConfig struct {
 	Resources ResourceConfig `json:"resources" yaml:"resources" validate:"required"`
 	// Action はリリースのアクション設定
 	Action ReleaseActionConfig `json:"action" yaml:"action" validate:"required"`
+	// Env はリリースのコマンドに渡す環境変数
+	// 値の形式は EnvValue を参照
+	Env map[string]EnvValue `json:"env,omitempty" yaml:"env,omitempty" validate:"omitempty,dive,keys,envname,endkeys,envvalue"`
 }
 
 // ResourceConfig はリソース設定を表す
 type ResourceConfig struct {
-	// CPU はCPUリソースの量
-	CPU string `json:"cpu" yaml:"cpu" validate:"required"`
-	// Memory はメモリリソースの量
-	Memory string `json:"memory" yaml:"memory" validate:"required"`
+	// CPU はCPUリソースの量 (例: `500m`, `2`)
+	CPU Quantity `json:"cpu" yaml:"cpu" validate:"required,quantity"`
+	// Memory はメモリリソースの量 (例: `256Mi`, `1G`)
+	Memory Quantity `json:"memory" yaml:"memory" validate:"required,quantity"`
 }
 
 // ReleaseActionConfig はリリースアクションの設定を表す
@@ -65,13 +78,19 @@ type ServiceConfig struct {
 	// Command はサービスの起動コマンド
 	Command []string `json:"command" yaml:"command" validate:"required,min=1,required"`
 	// HTTP はサービスのHTTP設定
-	HTTP []ServiceHTTPConfig `json:"http,omitempty" yaml:"http,omitempty"`
+	HTTP []ServiceHTTPConfig `json:"http,omitempty" yaml:"http,omitempty" validate:"omitempty,dive"`
 	// Healthcheck はサービスのヘルスチェック設定
+	// Probes.Readiness と同じ役割を持ち、同時に指定することはできない
 	Healthcheck *HealthcheckConfig `json:"healthcheck,omitempty" yaml:"healthcheck,omitempty"`
+	// Probes はサービスの役割ごとのヘルスチェック設定
+	Probes *ProbesConfig `json:"probes,omitempty" yaml:"probes,omitempty"`
 	// Scale はサービスのスケーリング設定
 	Scale *ServiceScaleConfig `json:"scale,omitempty" yaml:"scale,omitempty"`
 	// MachineConfig はサービスのマシン設定
 	MachineConfig *MachineConfig `json:"machine_config,omitempty" yaml:"machine_config,omitempty"`
+	// Env はサービスに渡す環境変数
+	// 値の形式は EnvValue を参照
+	Env map[string]EnvValue `json:"env,omitempty" yaml:"env,omitempty" validate:"omitempty,dive,keys,envname,endkeys,envvalue"`
 }
 
 // ServiceHTTPConfig はサービスのHTTP設定を表す
@@ -82,32 +101,94 @@ type ServiceHTTPConfig struct {
 	ForceHTTPS bool `json:"force_https,omitempty" yaml:"force_https,omitempty"`
 }
 
+// ProbesConfig はサービスの役割ごとのヘルスチェック設定を表す
+type ProbesConfig struct {
+	// Readiness はサービスがリクエストを受け付けられるかどうかを判定するヘルスチェック
+	// ServiceConfig.Healthcheck と同時に指定することはできない
+	Readiness *HealthcheckConfig `json:"readiness,omitempty" yaml:"readiness,omitempty"`
+	// Liveness はサービスが動作し続けているかどうかを判定するヘルスチェック
+	// 失敗した場合はサービスを再起動する
+	Liveness *HealthcheckConfig `json:"liveness,omitempty" yaml:"liveness,omitempty"`
+	// Startup はサービスの起動が完了したかどうかを判定するヘルスチェック
+	// 成功するまで Readiness と Liveness は実行されない
+	Startup *HealthcheckConfig `json:"startup,omitempty" yaml:"startup,omitempty"`
+}
+
 // HealthcheckConfig はサービスのヘルスチェック設定を表す
+// HTTP, Process, TCP, GRPC のいずれか1つのみを指定する
 type HealthcheckConfig struct {
 	// HTTP はHTTPヘルスチェックの設定
-	HTTP *HealthcheckHTTPConfig `json:"http,omitempty" yaml:"http,omitempty" validate:"required_without=Process"`
+	HTTP *HealthcheckHTTPConfig `json:"http,omitempty" yaml:"http,omitempty" validate:"required_without_all=Process TCP GRPC,excluded_with=Process TCP GRPC"`
 	// Process はプロセスヘルスチェックの設定
-	Process *HealthcheckProcessConfig `json:"process,omitempty" yaml:"process,omitempty" validate:"required_without=HTTP"`
+	Process *HealthcheckProcessConfig `json:"process,omitempty" yaml:"process,omitempty" validate:"excluded_with=TCP GRPC"`
+	// TCP はTCPヘルスチェックの設定
+	TCP *HealthcheckTCPConfig `json:"tcp,omitempty" yaml:"tcp,omitempty" validate:"excluded_with=GRPC"`
+	// GRPC はgRPCヘルスチェックの設定
+	GRPC *HealthcheckGRPCConfig `json:"grpc,omitempty" yaml:"grpc,omitempty"`
+	// InitialDelay はサービスの起動から最初のヘルスチェックまでの待ち時間 (例: `5s`)
+	// 省略した場合は待たずに開始する
+	InitialDelay Duration `json:"initial_delay,omitempty" yaml:"initial_delay,omitempty" validate:"omitempty,duration"`
+	// Interval はヘルスチェックの間隔
+	// 0より大きくなければならず、省略した場合は DefaultHealthcheckInterval
+	Interval Duration `json:"interval,omitempty" yaml:"interval,omitempty" validate:"omitempty,positive_duration"`
+	// Timeout は1回のヘルスチェックの制限時間
+	// 0より大きくなければならず、省略した場合は DefaultHealthcheckTimeout
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty" validate:"omitempty,positive_duration"`
+	// SuccessThreshold は失敗の後、正常とみなすまでに必要な連続した成功の回数
+	// 省略した場合は DefaultHealthcheckSuccessThreshold
+	SuccessThreshold int `json:"success_threshold,omitempty" yaml:"success_threshold,omitempty" validate:"omitempty,min=1"`
+	// FailureThreshold は異常とみなすまでに必要な連続した失敗の回数
+	// 省略した場合は DefaultHealthcheckFailureThreshold
+	FailureThreshold int `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty" validate:"omitempty,min=1"`
 }
 
 // HealthcheckHTTPConfig はHTTPヘルスチェックの設定を表す
+// 2xx または 3xx のステータスコードを正常とみなす
 type HealthcheckHTTPConfig struct {
 	// Path はヘルスチェックのエンドポイントパス
 	Path string `json:"path" yaml:"path" validate:"required"`
+	// Port はヘルスチェックの対象のポート
+	// service.http の target_port のいずれかでなければならず、省略した場合は最初の target_port
+	Port int `json:"port,omitempty" yaml:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+	// Headers はヘルスチェックのリクエストに付与するヘッダー
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
 }
 
 // HealthcheckProcessConfig はプロセスヘルスチェックの設定を表す
+// コマンドが終了コード0で終了した場合を正常とみなす
 type HealthcheckProcessConfig struct {
 	// Command はヘルスチェックに使用するコマンド
 	Command []string `json:"command" yaml:"command" validate:"required,min=1,required"`
 }
 
+// HealthcheckTCPConfig はTCPヘルスチェックの設定を表す
+// ポートへの接続に成功した場合を正常とみなす
+type HealthcheckTCPConfig struct {
+	// Port はヘルスチェックの対象のポート
+	// service.http の target_port のいずれかでなければならず、省略した場合は最初の target_port
+	Port int `json:"port,omitempty" yaml:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+}
+
+// HealthcheckGRPCConfig はgRPCヘルスチェックの設定を表す
+// gRPC Health Checking Protocol の応答が `SERVING` の場合を正常とみなす
+type HealthcheckGRPCConfig struct {
+	// Port はヘルスチェックの対象のポート
+	// service.http の target_port のいずれかでなければならず、省略した場合は最初の target_port
+	Port int `json:"port,omitempty" yaml:"port,omitempty" validate:"omitempty,min=1,max=65535"`
+	// Service は Health Checking Protocol のリクエストに指定するサービス名
+	// 省略した場合はサーバー全体の状態を問い合わせる
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+}
+
 // ServiceScaleConfig はサービスのスケーリング設定を表す
 type ServiceScaleConfig struct {
 	// Min はサービスの最小インスタンス数
-	Min int `json:"min" yaml:"min" validate:"required,min=0"`
+	// 0 を指定した場合は負荷がない間インスタンスを停止する (scale-to-zero)
+	// 省略した場合に意図せず scale-to-zero にならないよう、必ず指定しなければならない
+	Min *int `json:"min" yaml:"min" validate:"required,min=0"`
 	// Max はサービスの最大インスタンス数
-	Max int `json:"max" yaml:"max" validate:"required,min=1"`
+	// Min 以上でなければならない
+	Max int `json:"max" yaml:"max" validate:"required,min=1,gtefield=Min"`
 	// Metric はスケーリングに使用するメトリクスの設定
 	Metric ServiceMetricConfig `json:"metric" yaml:"metric" validate:"required"`
 }
@@ -115,41 +196,156 @@ type ServiceScaleConfig struct {
 // ServiceMetricConfig はサービスのスケーリングメトリクスの設定を表す
 type ServiceMetricConfig struct {
 	// Type はメトリクスの種類
-	Type string `json:"type" yaml:"type" validate:"required"`
+	// `cpu`, `memory`, `rps`, `concurrency` のいずれか
+	Type string `json:"type" yaml:"type" validate:"required,oneof=cpu memory rps concurrency"`
 	// Threshold はスケーリングの閾値
+	// `cpu` と `memory` の場合は使用率 (1〜100%)、`rps` の場合は1インスタンスあたりの秒間リクエスト数、
+	// `concurrency` の場合は1インスタンスあたりの同時リクエスト数
 	Threshold int `json:"threshold" yaml:"threshold" validate:"required,min=1"`
 }
 
 // MachineConfig はサービスのマシン設定を表す
 type MachineConfig struct {
-	// CPU はマシンのCPUリソースの量
-	CPU string `json:"cpu" yaml:"cpu" validate:"required"`
-	// Memory はマシンのメモリリソースの量
-	Memory string `json:"memory" yaml:"memory" validate:"required"`
+	// CPU はマシンのCPUリソースの量 (例: `500m`, `2`)
+	CPU Quantity `json:"cpu" yaml:"cpu" validate:"required,quantity"`
+	// Memory はマシンのメモリリソースの量 (例: `256Mi`, `1G`)
+	Memory Quantity `json:"memory" yaml:"memory" validate:"required,quantity"`
 	// Flavor はマシンのフレーバー
 	Flavor string `json:"flavor,omitempty" yaml:"flavor,omitempty"`
 }
 
+// WorkerConfig はバックグラウンドプロセスの設定を表す
+// ServiceConfig と異なり、HTTPリクエストは受け付けない
+type WorkerConfig struct {
+	// Name はワーカーの名前
+	// サービスやジョブの名前と重複してはならない
+	Name string `json:"name" yaml:"name" validate:"required"`
+	// Command はワーカーの起動コマンド
+	Command []string `json:"command" yaml:"command" validate:"required,min=1"`
+	// Scale はワーカーのスケーリング設定
+	Scale *ServiceScaleConfig `json:"scale,omitempty" yaml:"scale,omitempty"`
+	// MachineConfig はワーカーのマシン設定
+	MachineConfig *MachineConfig `json:"machine_config,omitempty" yaml:"machine_config,omitempty"`
+	// Env はワーカーに渡す環境変数
+	// 値の形式は EnvValue を参照
+	Env map[string]EnvValue `json:"env,omitempty" yaml:"env,omitempty" validate:"omitempty,dive,keys,envname,endkeys,envvalue"`
+}
+
+// CronJobConfig は定期的に実行するジョブの設定を表す
+type CronJobConfig struct {
+	// Name はジョブの名前
+	// サービスやワーカーの名前と重複してはならない
+	Name string `json:"name" yaml:"name" validate:"required"`
+	// Schedule はジョブを実行するスケジュール
+	// 5フィールドの cron 式 (例: `0 3 * * *`) または `@hourly` や `@every 1h` のような記述子
+	// `@every` の間隔は1時間または1日を割り切れなければならない (例: `@every 15m`, `@every 6h`)
+	Schedule string `json:"schedule" yaml:"schedule" validate:"required,cron"`
+	// Command はジョブで実行するコマンド
+	Command []string `json:"command" yaml:"command" validate:"required,min=1"`
+	// Resources はジョブのリソース設定
+	Resources ResourceConfig `json:"resources" yaml:"resources" validate:"required"`
+	// ConcurrencyPolicy は前回の実行が終わる前に次の実行時刻になった場合の動作
+	// `Allow`, `Forbid`, `Replace` のいずれかで、省略した場合は `Allow`
+	ConcurrencyPolicy string `json:"concurrency_policy,omitempty" yaml:"concurrency_policy,omitempty" validate:"omitempty,oneof=Allow Forbid Replace"`
+	// Timeout はジョブの実行時間の上限 (例: `30m`)
+	// 省略した場合は上限なし
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty" validate:"omitempty,duration"`
+	// Env はジョブに渡す環境変数
+	// 値の形式は EnvValue を参照
+	Env map[string]EnvValue `json:"env,omitempty" yaml:"env,omitempty" validate:"omitempty,dive,keys,envname,endkeys,envvalue"`
+}
+
 // StageConfig はアプリケーションのステージ設定を表す
 type StageConfig struct {
 	// Name はステージの名前
 	Name string `json:"name" yaml:"name" validate:"required"`
 	// Policy はステージのポリシー設定
 	Policy StagePolicyConfig `json:"policy" yaml:"policy" validate:"required"`
+	// Overrides はステージで上書きする設定
+	// 上書きの規則は AppConfig.ForStage を参照
+	Overrides *StageOverridesConfig `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+}
+
+// StageOverridesConfig はステージごとに上書きする設定を表す
+type StageOverridesConfig struct {
+	// Build はビルド設定の上書き
+	Build *BuildOverridesConfig `json:"build,omitempty" yaml:"build,omitempty"`
+	// Service はサービス設定の上書き
+	Service *ServiceOverridesConfig `json:"service,omitempty" yaml:"service,omitempty"`
+	// Releases はリリース設定の上書き
+	// 指定した場合はリリース設定全体を置き換える
+	Releases []ReleaseConfig `json:"releases,omitempty" yaml:"releases,omitempty" validate:"omitempty,dive"`
+}
+
+// BuildOverridesConfig はビルド設定の上書きを表す
+type BuildOverridesConfig struct {
+	// BuildArgs はビルド引数の上書き
+	// キーごとにマージされ、同じキーはステージの値が優先される
+	BuildArgs map[string]string `json:"build_args,omitempty" yaml:"build_args,omitempty" validate:"omitempty,dive,nosecret"`
+}
+
+// ServiceOverridesConfig はサービス設定の上書きを表す
+type ServiceOverridesConfig struct {
+	// Command はサービスの起動コマンドの上書き
+	// 指定した場合はコマンド全体を置き換える
+	Command []string `json:"command,omitempty" yaml:"command,omitempty" validate:"omitempty,min=1"`
+	// Scale はスケーリング設定の上書き
+	// 指定したフィールドのみを上書きする
+	Scale *ServiceScaleOverridesConfig `json:"scale,omitempty" yaml:"scale,omitempty"`
+	// MachineConfig はマシン設定の上書き
+	// 指定したフィールドのみを上書きする
+	MachineConfig *MachineOverridesConfig `json:"machine_config,omitempty" yaml:"machine_config,omitempty"`
+	// Env はサービスの環境変数の上書き
+	// キーごとにマージされ、同じキーはステージの値が優先される
+	Env map[string]EnvValue `json:"env,omitempty" yaml:"env,omitempty" validate:"omitempty,dive,keys,envname,endkeys,envvalue"`
+}
+
+// ServiceScaleOverridesConfig はスケーリング設定の上書きを表す
+type ServiceScaleOverridesConfig struct {
+	// Min はサービスの最小インスタンス数の上書き
+	Min *int `json:"min,omitempty" yaml:"min,omitempty" validate:"omitempty,min=0"`
+	// Max はサービスの最大インスタンス数の上書き
+	Max *int `json:"max,omitempty" yaml:"max,omitempty" validate:"omitempty,min=1"`
+	// Metric はスケーリングに使用するメトリクスの設定の上書き
+	// 指定した場合はメトリクスの設定全体を置き換える
+	Metric *ServiceMetricConfig `json:"metric,omitempty" yaml:"metric,omitempty"`
+}
+
+// MachineOverridesConfig はマシン設定の上書きを表す
+type MachineOverridesConfig struct {
+	// CPU はマシンのCPUリソースの量の上書き
+	CPU Quantity `json:"cpu,omitempty" yaml:"cpu,omitempty" validate:"omitempty,quantity"`
+	// Memory はマシンのメモリリソースの量の上書き
+	Memory Quantity `json:"memory,omitempty" yaml:"memory,omitempty" validate:"omitempty,quantity"`
+	// Flavor はマシンのフレーバーの上書き
+	Flavor string `json:"flavor,omitempty" yaml:"flavor,omitempty"`
 }
 
 // StagePolicyConfig はステージのポリシー設定を表す
 type StagePolicyConfig struct {
 	// Type はポリシーの種類
-	// 現在は `branch` のみサポート
-	Type string `json:"type" yaml:"type" validate:"required"`
+	// `branch`, `tag`, `pull_request`, `manual` のいずれか
+	Type string `json:"type" yaml:"type" validate:"required,oneof=branch tag pull_request manual"`
 	// Branch はブランチポリシーの設定
 	// Type が `branch` の場合に必須
 	Branch *BranchConfig `json:"branch,omitempty" yaml:"branch,omitempty" validate:"required_if=Type branch"`
+	// Tag はタグポリシーの設定
+	// Type が `tag` の場合に必須
+	Tag *TagConfig `json:"tag,omitempty" yaml:"tag,omitempty" validate:"required_if=Type tag"`
 }
 
 // BranchConfig はブランチポリシーの設定を表す
 type BranchConfig struct {
 	// Name は対象のブランチ名
-	Name string `json:"name" yaml:"name" validate:"required"`
+	// `release/*` のような glob パターンや、`/^release\/.+$/` のようにスラッシュで囲んだ正規表現も指定できる
+	Name string `json:"name" yaml:"name" validate:"required,refpattern"`
+}
+
+// TagConfig はタグポリシーの設定を表す
+type TagConfig struct {
+	// Pattern は対象のタグ名
+	// BranchConfig.Name と同様に glob パターンや正規表現を指定できる
+	Pattern string `json:"pattern" yaml:"pattern" validate:"required,refpattern"`
+	// Semver はセマンティックバージョン (例: `v1.2.3`) のタグのみを対象とするかどうか
+	Semver bool `json:"semver,omitempty" yaml:"semver,omitempty"`
 }
@@ -2,8 +2,7 @@ package apispec
 
 import (
 	"testing"
-
-	"github.com/go-playground/validator/v10"
+	"time"
 )
 
 func TestAppConfig_Validate(t *testing.T) {
@@ -16,6 +15,7 @@ func TestAppConfig_Validate(t *testing.T) {
 		{
 			name: "必須フィールドがすべて設定されている場合、エラーにならない",
 			config: AppConfig{
+				Version: LatestVersion,
 				AppName: "myapp",
 				Build: BuildConfig{
 					Image: "myapp:latest",
@@ -24,8 +24,8 @@ func TestAppConfig_Validate(t *testing.T) {
 					{
 						Name: "release-v1",
 						Resources: ResourceConfig{
-							CPU:    "500m",
-							Memory: "256Mi",
+							CPU:    MustParseQuantity("500m"),
+							Memory: MustParseQuantity("256Mi"),
 						},
 						Action: ReleaseActionConfig{
 							Command: []string{"echo", "deploy"},
@@ -42,6 +42,7 @@ func TestAppConfig_Validate(t *testing.T) {
 		{
 			name: "Serviceが設定されていない場合、エラーになる",
 			config: AppConfig{
+				Version: LatestVersion,
 				AppName: "myapp",
 				Build: BuildConfig{
 					Image: "myapp:latest",
@@ -50,8 +51,8 @@ func TestAppConfig_Validate(t *testing.T) {
 					{
 						Name: "release-v1",
 						Resources: ResourceConfig{
-							CPU:    "500m",
-							Memory: "256Mi",
+							CPU:    MustParseQuantity("500m"),
+							Memory: MustParseQuantity("256Mi"),
 						},
 						Action: ReleaseActionConfig{
 							Command: []string{"echo", "deploy"},
@@ -98,12 +99,59 @@ func TestBuildConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "DockerfileとDockerContextが設定されている場合、エラーにならない",
+			config: BuildConfig{
+				Dockerfile:    "docker/Dockerfile",
+				DockerContext: ".",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "ImageもDockerfileも設定されていない場合、エラーになる",
+			config:  BuildConfig{},
+			wantErr: true,
+		},
+		{
+			name: "ImageとDockerfileがともに設定されている場合、エラーになる",
+			config: BuildConfig{
+				Image:      "myapp:latest",
+				Dockerfile: "Dockerfile",
+			},
+			wantErr: true,
+		},
+		{
+			name: "ImageとBuildArgsがともに設定されている場合、エラーになる",
+			config: BuildConfig{
+				Image: "myapp:latest",
+				BuildArgs: map[string]string{
+					"ARG1": "value1",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Imageと空のBuildArgsが設定されている場合、エラーにならない",
+			config: BuildConfig{
+				Image:     "myapp:latest",
+				BuildArgs: map[string]string{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ImageとDockerContextがともに設定されている場合、エラーになる",
+			config: BuildConfig{
+				Image:         "myapp:latest",
+				DockerContext: ".",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BuildConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -126,8 +174,8 @@ func TestReleaseConfig_Validate(t *testing.T) {
 					Command: []string{"echo", "deploy"},
 				},
 				Resources: ResourceConfig{
-					CPU:    "500m",
-					Memory: "256Mi",
+					CPU:    MustParseQuantity("500m"),
+					Memory: MustParseQuantity("256Mi"),
 				},
 			},
 			wantErr: false,
@@ -139,8 +187,8 @@ func TestReleaseConfig_Validate(t *testing.T) {
 					Command: []string{"echo", "deploy"},
 				},
 				Resources: ResourceConfig{
-					CPU:    "500m",
-					Memory: "256Mi",
+					CPU:    MustParseQuantity("500m"),
+					Memory: MustParseQuantity("256Mi"),
 				},
 			},
 			wantErr: true,
@@ -153,8 +201,8 @@ func TestReleaseConfig_Validate(t *testing.T) {
 					Command: []string{},
 				},
 				Resources: ResourceConfig{
-					CPU:    "500m",
-					Memory: "256Mi",
+					CPU:    MustParseQuantity("500m"),
+					Memory: MustParseQuantity("256Mi"),
 				},
 			},
 			wantErr: true,
@@ -174,7 +222,7 @@ func TestReleaseConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReleaseConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -191,22 +239,22 @@ func TestResourceConfig_Validate(t *testing.T) {
 		{
 			name: "CPUとMemoryが設定されている場合、エラーにならない",
 			config: ResourceConfig{
-				CPU:    "500m",
-				Memory: "256Mi",
+				CPU:    MustParseQuantity("500m"),
+				Memory: MustParseQuantity("256Mi"),
 			},
 			wantErr: false,
 		},
 		{
 			name: "CPUが設定されていない場合、エラーになる",
 			config: ResourceConfig{
-				Memory: "256Mi",
+				Memory: MustParseQuantity("256Mi"),
 			},
 			wantErr: true,
 		},
 		{
 			name: "Memoryが設定されていない場合、エラーになる",
 			config: ResourceConfig{
-				CPU: "500m",
+				CPU: MustParseQuantity("500m"),
 			},
 			wantErr: true,
 		},
@@ -215,7 +263,7 @@ func TestResourceConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ResourceConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -247,7 +295,7 @@ func TestReleaseActionConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReleaseActionConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -288,7 +336,7 @@ func TestServiceConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ServiceConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -321,7 +369,7 @@ func TestServiceHTTPConfig_Validate(t *testing.T) {
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
 				t.Parallel()
-				err := validator.New().Struct(tt.config)
+				err := validateStruct(tt.config)
 				if (err != nil) != tt.wantErr {
 					t.Errorf("ServiceHTTPConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 				}
@@ -355,17 +403,60 @@ func TestHealthcheckConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "TCPが設定されている場合、エラーにならない",
+			config: HealthcheckConfig{
+				TCP: &HealthcheckTCPConfig{Port: 8080},
+			},
+			wantErr: false,
+		},
+		{
+			name: "GRPCとタイミングが設定されている場合、エラーにならない",
+			config: HealthcheckConfig{
+				GRPC:             &HealthcheckGRPCConfig{Service: "myapp.v1.Greeter"},
+				InitialDelay:     MustParseDuration("5s"),
+				Interval:         MustParseDuration("10s"),
+				Timeout:          MustParseDuration("2s"),
+				SuccessThreshold: 1,
+				FailureThreshold: 3,
+			},
+			wantErr: false,
+		},
 		{
 			name:    "HTTPもProcessも設定されていない場合、エラーになる",
 			config:  HealthcheckConfig{},
 			wantErr: true,
 		},
+		{
+			name: "複数の種類が設定されている場合、エラーになる",
+			config: HealthcheckConfig{
+				HTTP: &HealthcheckHTTPConfig{Path: "/"},
+				TCP:  &HealthcheckTCPConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Intervalが負の場合、エラーになる",
+			config: HealthcheckConfig{
+				TCP:      &HealthcheckTCPConfig{},
+				Interval: NewDuration(-time.Second),
+			},
+			wantErr: true,
+		},
+		{
+			name: "FailureThresholdが負の場合、エラーになる",
+			config: HealthcheckConfig{
+				TCP:              &HealthcheckTCPConfig{},
+				FailureThreshold: -1,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("HealthcheckConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -392,12 +483,20 @@ func TestHealthcheckHTTPConfig_Validate(t *testing.T) {
 			config:  HealthcheckHTTPConfig{},
 			wantErr: true,
 		},
+		{
+			name: "Portが範囲外の場合、エラーになる",
+			config: HealthcheckHTTPConfig{
+				Path: "/",
+				Port: 70000,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("HealthcheckHTTPConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -429,7 +528,7 @@ func TestHealthcheckProcessConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("HealthcheckProcessConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -447,7 +546,7 @@ func TestServiceScaleConfig_Validate(t *testing.T) {
 		{
 			name: "MinとMaxが設定されている場合、エラーにならない",
 			config: ServiceScaleConfig{
-				Min: 1,
+				Min: ptr(1),
 				Max: 5,
 				Metric: ServiceMetricConfig{
 					Type:      "cpu",
@@ -457,20 +556,45 @@ func TestServiceScaleConfig_Validate(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "Minが設定されていない場合、エラーになる",
+			name: "Minが0の場合、エラーにならない",
 			config: ServiceScaleConfig{
+				Min: ptr(0),
 				Max: 5,
 				Metric: ServiceMetricConfig{
 					Type:      "cpu",
 					Threshold: 80,
 				},
 			},
+			wantErr: false,
+		},
+		{
+			name: "MinとMaxが等しい場合、エラーにならない",
+			config: ServiceScaleConfig{
+				Min: ptr(3),
+				Max: 3,
+				Metric: ServiceMetricConfig{
+					Type:      "cpu",
+					Threshold: 80,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "MinがMaxより大きい場合、エラーになる",
+			config: ServiceScaleConfig{
+				Min: ptr(5),
+				Max: 2,
+				Metric: ServiceMetricConfig{
+					Type:      "cpu",
+					Threshold: 80,
+				},
+			},
 			wantErr: true,
 		},
 		{
 			name: "Maxが設定されていない場合、エラーになる",
 			config: ServiceScaleConfig{
-				Min: 1,
+				Min: ptr(1),
 				Metric: ServiceMetricConfig{
 					Type:      "cpu",
 					Threshold: 80,
@@ -481,7 +605,7 @@ func TestServiceScaleConfig_Validate(t *testing.T) {
 		{
 			name: "Metricが設定されていない場合、エラーになる",
 			config: ServiceScaleConfig{
-				Min: 1,
+				Min: ptr(1),
 				Max: 5,
 			},
 			wantErr: true,
@@ -489,7 +613,18 @@ func TestServiceScaleConfig_Validate(t *testing.T) {
 		{
 			name: "Minが0未満の場合、エラーになる",
 			config: ServiceScaleConfig{
-				Min: -1,
+				Min: ptr(-1),
+				Max: 5,
+				Metric: ServiceMetricConfig{
+					Type:      "cpu",
+					Threshold: 80,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Minが設定されていない場合、エラーになる",
+			config: ServiceScaleConfig{
 				Max: 5,
 				Metric: ServiceMetricConfig{
 					Type:      "cpu",
@@ -501,7 +636,7 @@ func TestServiceScaleConfig_Validate(t *testing.T) {
 		{
 			name: "Maxが1未満の場合、エラーになる",
 			config: ServiceScaleConfig{
-				Min: 1,
+				Min: ptr(1),
 				Max: 0,
 				Metric: ServiceMetricConfig{
 					Type:      "cpu",
@@ -515,7 +650,7 @@ func TestServiceScaleConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ServiceScaleConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -552,12 +687,60 @@ func TestServiceMetricConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "未知のTypeの場合、エラーになる",
+			config: ServiceMetricConfig{
+				Type:      "latency",
+				Threshold: 80,
+			},
+			wantErr: true,
+		},
+		{
+			name: "TypeがcpuでThresholdが100の場合、エラーにならない",
+			config: ServiceMetricConfig{
+				Type:      "cpu",
+				Threshold: 100,
+			},
+			wantErr: false,
+		},
+		{
+			name: "TypeがcpuでThresholdが100を超える場合、エラーになる",
+			config: ServiceMetricConfig{
+				Type:      "cpu",
+				Threshold: 150,
+			},
+			wantErr: true,
+		},
+		{
+			name: "TypeがmemoryでThresholdが100を超える場合、エラーになる",
+			config: ServiceMetricConfig{
+				Type:      "memory",
+				Threshold: 101,
+			},
+			wantErr: true,
+		},
+		{
+			name: "TypeがrpsでThresholdが100を超える場合、エラーにならない",
+			config: ServiceMetricConfig{
+				Type:      "rps",
+				Threshold: 500,
+			},
+			wantErr: false,
+		},
+		{
+			name: "TypeがconcurrencyでThresholdが設定されている場合、エラーにならない",
+			config: ServiceMetricConfig{
+				Type:      "concurrency",
+				Threshold: 50,
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ServiceMetricConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -575,22 +758,22 @@ func TestMachineConfig_Validate(t *testing.T) {
 		{
 			name: "CPUとMemoryが設定されている場合、エラーにならない",
 			config: MachineConfig{
-				CPU:    "500m",
-				Memory: "256Mi",
+				CPU:    MustParseQuantity("500m"),
+				Memory: MustParseQuantity("256Mi"),
 			},
 			wantErr: false,
 		},
 		{
 			name: "CPUが設定されていない場合、エラーになる",
 			config: MachineConfig{
-				Memory: "256Mi",
+				Memory: MustParseQuantity("256Mi"),
 			},
 			wantErr: true,
 		},
 		{
 			name: "Memoryが設定されていない場合、エラーになる",
 			config: MachineConfig{
-				CPU: "500m",
+				CPU: MustParseQuantity("500m"),
 			},
 			wantErr: true,
 		},
@@ -599,10 +782,15 @@ func TestMachineConfig_Validate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			err := validator.New().Struct(tt.config)
+			err := validateStruct(tt.config)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("MachineConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+// ptr は v へのポインタを返す
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,15 @@
+package apispec
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// validateBuildConfig は Image と BuildArgs が同時に指定されていないことを検証する
+// 空の build_args (`build_args: {}`) は省略した場合と同じに扱うため、
+// nil でないマップを指定とみなす validate タグの excluded_with ではなくここで検証する
+func validateBuildConfig(sl validator.StructLevel) {
+	b := sl.Current().Interface().(BuildConfig)
+	if b.Image != "" && len(b.BuildArgs) > 0 {
+		sl.ReportError(b.BuildArgs, "build_args", "BuildArgs", "excluded_with", "Image")
+	}
+}
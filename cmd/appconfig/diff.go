@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// diffReport は diff の -o json の出力を表す
+type diffReport struct {
+	Old     string           `json:"old"`
+	New     string           `json:"new"`
+	Stage   string           `json:"stage,omitempty"`
+	Changes []apispec.Change `json:"changes"`
+}
+
+func (c *cli) diff(args []string) int {
+	fs := c.flagSet("diff", "[-o text|json] [-stage name] [-strict] <old> <new>")
+	output := fs.String("o", "text", "output format: text or json")
+	stage := fs.String("stage", "", "compare the configs with the overrides of the stage applied")
+	strict := fs.Bool("strict", false, "report unknown keys as errors")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if *output != "text" && *output != "json" {
+		return c.usageError(fs, fmt.Sprintf("unknown output format %q", *output))
+	}
+	if fs.NArg() != 2 {
+		return c.usageError(fs, "exactly two files are required")
+	}
+	if fs.Arg(0) == "-" && fs.Arg(1) == "-" {
+		return c.usageError(fs, "only one of the files can be read from stdin")
+	}
+	var opts []apispec.LoadOption
+	if *strict {
+		opts = append(opts, apispec.WithStrict())
+	}
+
+	configs := make([]*apispec.AppConfig, 2)
+	for i, file := range fs.Args() {
+		cfg, err := c.loadConfig(file, *stage, opts)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return exitFailure
+		}
+		configs[i] = cfg
+	}
+	changes := apispec.Diff(configs[0], configs[1])
+
+	if *output == "json" {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		report := diffReport{Old: fs.Arg(0), New: fs.Arg(1), Stage: *stage, Changes: changes}
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(c.stderr, "appconfig diff: %v\n", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	for _, ch := range changes {
+		if err := writeChange(c.stdout, ch); err != nil {
+			fmt.Fprintf(c.stderr, "appconfig diff: %v\n", err)
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// loadConfig は file の設定を読み込み、stage が指定された場合はその上書き設定を適用する
+// file が `-` の場合は標準入力から読み込む
+func (c *cli) loadConfig(file, stage string, opts []apispec.LoadOption) (*apispec.AppConfig, error) {
+	var cfg *apispec.AppConfig
+	var err error
+	if file == "-" {
+		cfg, err = apispec.Load(c.stdin, apispec.FormatAuto, opts...)
+		if err != nil {
+			err = fmt.Errorf("<stdin>: %w", err)
+		}
+	} else {
+		cfg, err = apispec.LoadFile(file, opts...)
+	}
+	if err != nil || stage == "" {
+		return cfg, err
+	}
+	resolved, err := cfg.ForStage(stage)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &resolved.Config, nil
+}
+
+// writeChange は変更を `+` (追加)、`-` (削除)、`~` (変更) に続けて1行で書き込む
+// 値はJSONで表す
+func writeChange(w io.Writer, ch apispec.Change) error {
+	format := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+	var err error
+	switch ch.Kind {
+	case apispec.ChangeAdded:
+		_, err = fmt.Fprintf(w, "+ %s: %s\n", ch.Path, format(ch.New))
+	case apispec.ChangeRemoved:
+		_, err = fmt.Fprintf(w, "- %s: %s\n", ch.Path, format(ch.Old))
+	default:
+		_, err = fmt.Fprintf(w, "~ %s: %s -> %s\n", ch.Path, format(ch.Old), format(ch.New))
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+func (c *cli) fmt(args []string) int {
+	fs := c.flagSet("fmt", "[-check | -w] [file]...")
+	check := fs.Bool("check", false, "list files whose formatting differs and exit with 1 instead of printing them")
+	write := fs.Bool("w", false, "write the result to the source file instead of stdout")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if *check && *write {
+		return c.usageError(fs, "-check and -w cannot be used together")
+	}
+
+	if fs.NArg() == 0 {
+		if *write {
+			return c.usageError(fs, "-w requires files")
+		}
+		data, err := io.ReadAll(c.stdin)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "appconfig fmt: %v\n", err)
+			return exitFailure
+		}
+		return c.formatSource("<stdin>", data, apispec.FormatAuto, *check)
+	}
+
+	code := exitOK
+	for _, file := range fs.Args() {
+		if c.formatFile(file, *check, *write) != exitOK {
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+// formatFile は file を整形する
+func (c *cli) formatFile(file string, check, write bool) int {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig fmt: %v\n", err)
+		return exitFailure
+	}
+	if !write {
+		return c.formatSource(file, data, apispec.FormatFromPath(file), check)
+	}
+	formatted, err := apispec.FormatSource(data, apispec.FormatFromPath(file))
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig fmt: %s: %v\n", file, err)
+		return exitFailure
+	}
+	if bytes.Equal(data, formatted) {
+		return exitOK
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig fmt: %v\n", err)
+		return exitFailure
+	}
+	if err := os.WriteFile(file, formatted, info.Mode().Perm()); err != nil {
+		fmt.Fprintf(c.stderr, "appconfig fmt: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// formatSource は data を整形して出力する
+// check が true の場合は整形結果が data と異なる場合に name を出力し、1を返す
+func (c *cli) formatSource(name string, data []byte, format apispec.Format, check bool) int {
+	formatted, err := apispec.FormatSource(data, format)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig fmt: %s: %v\n", name, err)
+		return exitFailure
+	}
+	if check {
+		if bytes.Equal(data, formatted) {
+			return exitOK
+		}
+		fmt.Fprintln(c.stdout, name)
+		return exitFailure
+	}
+	if _, err := c.stdout.Write(formatted); err != nil {
+		fmt.Fprintf(c.stderr, "appconfig fmt: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	apispec "github.com/tacokumo/appconfig"
+	"github.com/tacokumo/appconfig/importer"
+)
+
+func (c *cli) importApp(args []string) int {
+	fs := c.flagSet("import", "[-name name] [-image image] [-port port] [-o file] [-format yaml|json] [path]")
+	name := fs.String("name", "", "name of the app (defaults to the name in the source or the directory name)")
+	image := fs.String("image", "", "container image to use instead of the build settings of the source")
+	port := fs.Int("port", importer.DefaultPort, "target port of the web process when the source does not declare one")
+	output := fs.String("o", "", "write the config to the file instead of stdout")
+	format := fs.String("format", "", "output format: yaml or json (defaults to the extension of -o, or yaml)")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 1 {
+		return c.usageError(fs, "at most one path is allowed")
+	}
+	var outFormat apispec.Format
+	switch *format {
+	case "":
+		outFormat = apispec.FormatFromPath(*output)
+	case "yaml":
+		outFormat = apispec.FormatYAML
+	case "json":
+		outFormat = apispec.FormatJSON
+	default:
+		return c.usageError(fs, fmt.Sprintf("unknown output format %q", *format))
+	}
+	path := "."
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	res, err := importer.Load(path, importer.Options{AppName: *name, Image: *image, Port: *port})
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig import: %v\n", err)
+		return exitFailure
+	}
+	for _, w := range res.Warnings {
+		fmt.Fprintf(c.stderr, "warning: %s\n", w)
+	}
+	data, err := apispec.Marshal(res.Config, outFormat)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig import: %v\n", err)
+		return exitFailure
+	}
+	if *output == "" {
+		_, err = c.stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig import: %v\n", err)
+		return exitFailure
+	}
+
+	// 変換した設定は利用者が補う前提のため、出力した上で不足している設定を報告する
+	if err := res.Config.Validate(); err != nil {
+		var verrs apispec.ValidationErrors
+		if !errors.As(err, &verrs) {
+			fmt.Fprintf(c.stderr, "appconfig import: %v\n", err)
+			return exitFailure
+		}
+		fmt.Fprintln(c.stderr, "appconfig import: the imported config needs to be completed:")
+		for _, e := range verrs {
+			fmt.Fprintf(c.stderr, "  %s: %s\n", e.Path, e.LocalizedMessage(apispec.LocaleEnglish))
+		}
+		return exitFailure
+	}
+	return exitOK
+}
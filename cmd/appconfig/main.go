@@ -0,0 +1,117 @@
+// appconfig は設定ファイルの検証、整形、移行、比較、JSON Schema の出力、ローカルでの実行、既存の定義からの変換を行うコマンド
+//
+// 使い方:
+//
+//	appconfig validate [-o text|json] [-strict] [-lang ja|en] <file>...
+//	appconfig fmt [-check | -w] [file]...
+//	appconfig migrate [-check | -w] [file]...
+//	appconfig diff [-o text|json] [-stage name] [-strict] <old> <new>
+//	appconfig schema [-openapi]
+//	appconfig run [-stage name] [-secrets file] [-restart policy] [-max-restarts n] [-shutdown-timeout duration] [-strict] <file>
+//	appconfig import [-name name] [-image image] [-port port] [-o file] [-format yaml|json] [path]
+//
+// 終了コードは成功した場合は0、検証や整形、移行の確認で問題が見つかった場合やコマンドが失敗した場合は1、
+// 引数が不正な場合は2
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// cli はコマンドの入出力を表す
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// subcommand はサブコマンドを表す
+type subcommand struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) int
+}
+
+var subcommands = []subcommand{
+	{name: "validate", summary: "validate config files", run: (*cli).validate},
+	{name: "fmt", summary: "format config files with canonical key ordering", run: (*cli).fmt},
+	{name: "migrate", summary: "migrate config files to the latest version", run: (*cli).migrate},
+	{name: "diff", summary: "show the changes between two config files", run: (*cli).diff},
+	{name: "schema", summary: "print the JSON Schema of the config file", run: (*cli).schema},
+	{name: "run", summary: "run the releases and the service locally", run: (*cli).runApp},
+	{name: "import", summary: "convert a Procfile, app.json or compose file to a config file", run: (*cli).importApp},
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// run は args のサブコマンドを実行し、終了コードを返す
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return exitUsage
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		c.usage()
+		return exitOK
+	}
+	for _, sub := range subcommands {
+		if sub.name == name {
+			return sub.run(c, args[1:])
+		}
+	}
+	fmt.Fprintf(c.stderr, "appconfig: unknown command %q\n", name)
+	c.usage()
+	return exitUsage
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "usage: appconfig <command> [arguments]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "commands:")
+	for _, sub := range subcommands {
+		fmt.Fprintf(c.stderr, "  %-9s %s\n", sub.name, sub.summary)
+	}
+}
+
+// flagSet は name のサブコマンドの引数を解析する FlagSet を作成する
+func (c *cli) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: appconfig %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse は args を解析し、失敗した場合は終了コードを返す
+func parse(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// usageError は msg と使い方を出力し、引数が不正であることを表す終了コードを返す
+func (c *cli) usageError(fs *flag.FlagSet, msg string) int {
+	fmt.Fprintf(c.stderr, "appconfig %s: %s\n", fs.Name(), msg)
+	fs.Usage()
+	return exitUsage
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// writeFile は t の一時ディレクトリに name のファイルを作成し、そのパスを返す
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// execute は args でコマンドを実行し、終了コードと出力を返す
+func execute(stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	c := &cli{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut}
+	code = c.run(args)
+	return code, out.String(), errOut.String()
+}
+
+const stageInvalid = `app_name: myapp
+build:
+  image: myapp:latest
+releases: []
+service:
+  name: web
+  command: [start]
+  scale:
+    min: 1
+    max: 4
+    metric: {type: cpu, threshold: 70}
+stages:
+  - name: production
+    policy:
+      type: branch
+      branch:
+        name: main
+    overrides:
+      service:
+        scale:
+          min: 8
+`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	invalid := writeFile(t, "invalid.yaml", "app_name: myapp\nbuild:\n  image: myapp:latest\nreleases: []\nservice:\n  name: web\n  comand: [start]\n")
+	stage := writeFile(t, "stage.yaml", stageInvalid)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		want     []string
+	}{
+		{
+			name:     "正しい設定ファイルの場合、okを出力する",
+			args:     []string{"validate", "../../testdata/valid.yaml", "../../testdata/valid.json"},
+			wantCode: exitOK,
+			want:     []string{"../../testdata/valid.yaml: ok\n", "../../testdata/valid.json: ok\n"},
+		},
+		{
+			name:     "不正な設定ファイルの場合、位置とパスとメッセージを出力する",
+			args:     []string{"validate", "-lang", "en", invalid},
+			wantCode: exitFailure,
+			want:     []string{invalid + ":5:1: service.command: command is a required field\n"},
+		},
+		{
+			name:     "strictの場合、未知のキーを報告する",
+			args:     []string{"validate", "-strict", invalid},
+			wantCode: exitFailure,
+			want:     []string{invalid + `:7:3: service.comand: unknown field "comand", did you mean "command"?` + "\n"},
+		},
+		{
+			name:     "上書き設定を適用したステージが不正な場合、ステージ名とともに報告する",
+			args:     []string{"validate", "-lang", "en", stage},
+			wantCode: exitFailure,
+			want:     []string{stage + ":10:5: service.scale.max: max must be greater than or equal to min (stage production)\n"},
+		},
+		{
+			name:     "ファイルが存在しない場合、失敗する",
+			args:     []string{"validate", "no-such-file.yaml"},
+			wantCode: exitFailure,
+			want:     []string{"no-such-file.yaml: open no-such-file.yaml"},
+		},
+		{
+			name:     "ファイルを指定しない場合、使い方の誤りとして扱う",
+			args:     []string{"validate"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "未知の出力形式の場合、使い方の誤りとして扱う",
+			args:     []string{"validate", "-o", "xml", invalid},
+			wantCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			code, stdout, stderr := execute("", tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d\nstdout:\n%s\nstderr:\n%s", code, tt.wantCode, stdout, stderr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout does not contain %q:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_JSON(t *testing.T) {
+	t.Parallel()
+	invalid := writeFile(t, "invalid.yaml", "app_name: myapp\nbuild:\n  image: myapp:latest\nreleases: []\nservice:\n  name: web\n")
+
+	code, stdout, _ := execute("", "validate", "-o", "json", "-lang", "en", "../../testdata/valid.yaml", invalid)
+	if code != exitFailure {
+		t.Errorf("exit code = %d, want %d", code, exitFailure)
+	}
+	var got validateReport
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	want := validateReport{
+		Valid: false,
+		Files: []fileResult{
+			{File: "../../testdata/valid.yaml", Valid: true},
+			{File: invalid, Errors: []diagnostic{{
+				Path:    "service.command",
+				Rule:    "required",
+				Message: "command is a required field",
+				Line:    5,
+				Column:  1,
+			}}},
+		},
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("report = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	t.Parallel()
+	const unformatted = "service:\n    name: web\n    command: [start]\napp_name: myapp\n"
+	const formatted = "app_name: myapp\nservice:\n  name: web\n  command: [start]\n"
+
+	t.Run("ファイルを指定しない場合、標準入力を整形して出力する", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, _ := execute(unformatted, "fmt")
+		if code != exitOK || stdout != formatted {
+			t.Errorf("fmt = %d, %q, want %d, %q", code, stdout, exitOK, formatted)
+		}
+	})
+	t.Run("checkの場合、整形されていないファイルを出力して失敗する", func(t *testing.T) {
+		t.Parallel()
+		bad := writeFile(t, "bad.yaml", unformatted)
+		good := writeFile(t, "good.yaml", formatted)
+		code, stdout, _ := execute("", "fmt", "-check", bad, good)
+		if code != exitFailure || stdout != bad+"\n" {
+			t.Errorf("fmt -check = %d, %q, want %d, %q", code, stdout, exitFailure, bad+"\n")
+		}
+		if code, _, _ := execute("", "fmt", "-check", good); code != exitOK {
+			t.Errorf("fmt -check of a formatted file = %d, want %d", code, exitOK)
+		}
+	})
+	t.Run("wの場合、ファイルを書き換える", func(t *testing.T) {
+		t.Parallel()
+		file := writeFile(t, "app.yaml", unformatted)
+		if code, stdout, stderr := execute("", "fmt", "-w", file); code != exitOK || stdout != "" {
+			t.Fatalf("fmt -w = %d, %q, %q", code, stdout, stderr)
+		}
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != formatted {
+			t.Errorf("file = %q, want %q", got, formatted)
+		}
+	})
+	t.Run("checkとwを同時に指定した場合、使い方の誤りとして扱う", func(t *testing.T) {
+		t.Parallel()
+		if code, _, _ := execute("", "fmt", "-check", "-w", "app.yaml"); code != exitUsage {
+			t.Errorf("exit code = %d, want %d", code, exitUsage)
+		}
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+	const legacy = "app_name: myapp\nservice:\n  name: web\n"
+	const migrated = "version: v1\napp_name: myapp\nservice:\n  name: web\n"
+
+	t.Run("ファイルを指定しない場合、標準入力を移行して出力する", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, _ := execute(legacy, "migrate")
+		if code != exitOK || stdout != migrated {
+			t.Errorf("migrate = %d, %q, want %d, %q", code, stdout, exitOK, migrated)
+		}
+	})
+	t.Run("checkの場合、最新のバージョンでないファイルを出力して失敗する", func(t *testing.T) {
+		t.Parallel()
+		old := writeFile(t, "old.yaml", legacy)
+		latest := writeFile(t, "latest.yaml", migrated)
+		code, stdout, _ := execute("", "migrate", "-check", old, latest)
+		if code != exitFailure || stdout != old+"\n" {
+			t.Errorf("migrate -check = %d, %q, want %d, %q", code, stdout, exitFailure, old+"\n")
+		}
+	})
+	t.Run("wの場合、最新のバージョンでないファイルのみ書き換える", func(t *testing.T) {
+		t.Parallel()
+		old := writeFile(t, "old.yaml", legacy)
+		const unformatted = "version: v1\nservice: {name: web}\napp_name: myapp\n"
+		latest := writeFile(t, "latest.yaml", unformatted)
+		code, stdout, stderr := execute("", "migrate", "-w", old, latest)
+		if code != exitOK || stdout != "" {
+			t.Fatalf("migrate -w = %d, %q, %q", code, stdout, stderr)
+		}
+		if want := old + ": migrated from v0 to v1\n"; stderr != want {
+			t.Errorf("stderr = %q, want %q", stderr, want)
+		}
+		for file, want := range map[string]string{old: migrated, latest: unformatted} {
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("%s = %q, want %q", file, got, want)
+			}
+		}
+	})
+	t.Run("未知のバージョンの場合、失敗する", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := execute("version: v9\n", "migrate")
+		if code != exitFailure || !strings.Contains(stderr, `unknown version "v9"`) {
+			t.Errorf("migrate = %d, %q", code, stderr)
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	const old = "../../testdata/stages.yaml"
+	b, err := os.ReadFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := strings.NewReplacer("NODE_ENV: production", "NODE_ENV: staging", "max: 10", "max: 20", "    LOG_FORMAT: json\n", "").Replace(string(b))
+	new := writeFile(t, "new.yaml", changed)
+
+	tests := []struct {
+		name     string
+		stdin    string
+		args     []string
+		wantCode int
+		want     string
+	}{
+		{
+			name:     "変更を1行ずつ出力する",
+			args:     []string{"diff", old, new},
+			wantCode: exitOK,
+			want:     "~ build.build_args.NODE_ENV: \"production\" -> \"staging\"\n~ service.scale.max: 10 -> 20\n- service.env.LOG_FORMAT: \"json\"\n",
+		},
+		{
+			name:     "stageの場合、上書き設定を適用した設定を比較する",
+			args:     []string{"diff", "-stage", "staging", old, new},
+			wantCode: exitOK,
+			want:     "~ build.build_args.NODE_ENV: \"production\" -> \"staging\"\n",
+		},
+		{
+			name:     "-の場合、標準入力から読み込む",
+			stdin:    changed,
+			args:     []string{"diff", new, "-"},
+			wantCode: exitOK,
+			want:     "",
+		},
+		{
+			name:     "ファイルが2つでない場合、使い方の誤りとして扱う",
+			args:     []string{"diff", old},
+			wantCode: exitUsage,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			code, stdout, stderr := execute(tt.stdin, tt.args...)
+			if code != tt.wantCode || stdout != tt.want {
+				t.Errorf("diff = %d, %q, want %d, %q\nstderr:\n%s", code, stdout, tt.wantCode, tt.want, stderr)
+			}
+		})
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	t.Parallel()
+	const old = "../../testdata/stages.yaml"
+	b, err := os.ReadFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new := writeFile(t, "new.yaml", strings.Replace(string(b), "cpu: 500m", "cpu: \"1\"", 1))
+
+	code, stdout, _ := execute("", "diff", "-o", "json", old, new)
+	if code != exitOK {
+		t.Errorf("exit code = %d, want %d", code, exitOK)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	want := map[string]any{
+		"old": old,
+		"new": new,
+		"changes": []any{map[string]any{
+			"path": "releases[name=migrate].resources.cpu",
+			"kind": "modified",
+			"old":  "500m",
+			"new":  "1",
+		}},
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("report = %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		args []string
+		file string
+	}{
+		{name: "JSON Schemaを出力する", args: []string{"schema"}, file: "../../schema/appconfig.schema.json"},
+		{name: "openapiの場合、OpenAPIドキュメントを出力する", args: []string{"schema", "-openapi"}, file: "../../schema/openapi.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			want, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, stdout, _ := execute("", tt.args...)
+			if code != exitOK || stdout != string(want) {
+				t.Errorf("schema = %d, output differs from %s", code, tt.file)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	want, err := os.ReadFile("../../importer/testdata/heroku.golden.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ディレクトリを変換して出力し、警告を標準エラー出力に出力する", func(t *testing.T) {
+		t.Parallel()
+		code, stdout, stderr := execute("", "import", "../../importer/testdata/heroku")
+		if code != exitOK {
+			t.Fatalf("exit code = %d, want %d\nstderr:\n%s", code, exitOK, stderr)
+		}
+		if stdout != string(want) {
+			t.Errorf("stdout = %q, want %q", stdout, want)
+		}
+		if !strings.Contains(stderr, "warning: ") {
+			t.Errorf("stderr does not report warnings:\n%s", stderr)
+		}
+	})
+	t.Run("oの場合、拡張子の形式でファイルに書き込む", func(t *testing.T) {
+		t.Parallel()
+		file := filepath.Join(t.TempDir(), "appconfig.json")
+		if code, stdout, stderr := execute("", "import", "-o", file, "../../importer/testdata/heroku"); code != exitOK || stdout != "" {
+			t.Fatalf("import -o = %d, %q, %q", code, stdout, stderr)
+		}
+		cfg, err := apispec.LoadFile(file, apispec.WithStrict())
+		if err != nil {
+			t.Fatalf("LoadFile() error = %v", err)
+		}
+		if cfg.AppName != "shop" {
+			t.Errorf("app_name = %q, want %q", cfg.AppName, "shop")
+		}
+	})
+	t.Run("変換した設定が不正な場合、出力した上で失敗する", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		compose := "services:\n  web:\n    image: nginx\n    ports: [\"8080:80\"]\n"
+		if err := os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte(compose), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		code, stdout, stderr := execute("", "import", dir)
+		if code != exitFailure {
+			t.Errorf("exit code = %d, want %d", code, exitFailure)
+		}
+		if !strings.Contains(stdout, "command:") {
+			t.Errorf("stdout does not contain the imported config:\n%s", stdout)
+		}
+		if !strings.Contains(stderr, "service.command: ") {
+			t.Errorf("stderr does not report the missing command:\n%s", stderr)
+		}
+	})
+	t.Run("ファイルが見つからない場合、失敗する", func(t *testing.T) {
+		t.Parallel()
+		code, _, stderr := execute("", "import", t.TempDir())
+		if code != exitFailure || !strings.Contains(stderr, "no Procfile or compose file found") {
+			t.Errorf("import = %d, %q", code, stderr)
+		}
+	})
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+	src, err := os.ReadFile("main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, _, _ := strings.Cut(string(src), "package main")
+	for _, sub := range subcommands {
+		t.Run(sub.name, func(t *testing.T) {
+			t.Parallel()
+			code, _, stderr := execute("", sub.name, "-h")
+			if code != exitOK {
+				t.Fatalf("exit code = %d, want %d", code, exitOK)
+			}
+			usage, defaults, _ := strings.Cut(stderr, "\n")
+			usage = strings.TrimPrefix(usage, "usage: ")
+			// パッケージのドキュメントの使い方はサブコマンドの使い方と一致する
+			if !strings.Contains(doc, "//\t"+usage+"\n") {
+				t.Errorf("package doc does not contain %q", usage)
+			}
+			// すべてのフラグが使い方に含まれる
+			for _, line := range strings.Split(defaults, "\n") {
+				flag, ok := strings.CutPrefix(line, "  -")
+				if !ok {
+					continue
+				}
+				flag, _, _ = strings.Cut(flag, " ")
+				flag, _, _ = strings.Cut(flag, "\t")
+				if !strings.Contains(usage, "-"+flag+" ") && !strings.Contains(usage, "-"+flag+"]") {
+					t.Errorf("usage %q does not mention -%s", usage, flag)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	t.Parallel()
+	code, _, stderr := execute("", "lint")
+	if code != exitUsage {
+		t.Errorf("exit code = %d, want %d", code, exitUsage)
+	}
+	if !strings.Contains(stderr, `unknown command "lint"`) {
+		t.Errorf("stderr does not report the unknown command:\n%s", stderr)
+	}
+}
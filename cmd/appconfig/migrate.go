@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+func (c *cli) migrate(args []string) int {
+	fs := c.flagSet("migrate", "[-check | -w] [file]...")
+	check := fs.Bool("check", false, "list files that are not in the latest version and exit with 1 instead of printing them")
+	write := fs.Bool("w", false, "write the result to the source file instead of stdout")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if *check && *write {
+		return c.usageError(fs, "-check and -w cannot be used together")
+	}
+
+	if fs.NArg() == 0 {
+		if *write {
+			return c.usageError(fs, "-w requires files")
+		}
+		data, err := io.ReadAll(c.stdin)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "appconfig migrate: %v\n", err)
+			return exitFailure
+		}
+		return c.migrateSource("<stdin>", data, apispec.FormatAuto, *check)
+	}
+
+	code := exitOK
+	for _, file := range fs.Args() {
+		if c.migrateFile(file, *check, *write) != exitOK {
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+// migrateFile は file を最新のバージョンの形式に移行する
+// write が true の場合、既に最新のバージョンのファイルは書き換えない
+func (c *cli) migrateFile(file string, check, write bool) int {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig migrate: %v\n", err)
+		return exitFailure
+	}
+	if !write {
+		return c.migrateSource(file, data, apispec.FormatFromPath(file), check)
+	}
+	migrated, version, err := apispec.MigrateSource(data, apispec.FormatFromPath(file))
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig migrate: %s: %v\n", file, err)
+		return exitFailure
+	}
+	if version == apispec.LatestVersion {
+		return exitOK
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig migrate: %v\n", err)
+		return exitFailure
+	}
+	if err := os.WriteFile(file, migrated, info.Mode().Perm()); err != nil {
+		fmt.Fprintf(c.stderr, "appconfig migrate: %v\n", err)
+		return exitFailure
+	}
+	fmt.Fprintf(c.stderr, "%s: migrated from %s to %s\n", file, version, apispec.LatestVersion)
+	return exitOK
+}
+
+// migrateSource は data を最新のバージョンの形式に移行して出力する
+// check が true の場合は data が最新のバージョンでない場合に name を出力し、1を返す
+func (c *cli) migrateSource(name string, data []byte, format apispec.Format, check bool) int {
+	migrated, version, err := apispec.MigrateSource(data, format)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig migrate: %s: %v\n", name, err)
+		return exitFailure
+	}
+	if check {
+		if version == apispec.LatestVersion {
+			return exitOK
+		}
+		fmt.Fprintln(c.stdout, name)
+		return exitFailure
+	}
+	if _, err := c.stdout.Write(migrated); err != nil {
+		fmt.Fprintf(c.stderr, "appconfig migrate: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	apispec "github.com/tacokumo/appconfig"
+	"github.com/tacokumo/appconfig/runner"
+)
+
+func (c *cli) runApp(args []string) int {
+	fs := c.flagSet("run", "[-stage name] [-secrets file] [-restart policy] [-max-restarts n] [-shutdown-timeout duration] [-strict] <file>")
+	stage := fs.String("stage", "", "apply the overrides of the stage")
+	secrets := fs.String("secrets", "", "YAML or JSON file to resolve secret:// references from")
+	restart := fs.String("restart", string(runner.RestartOnFailure), "restart policy of the service: never, on-failure or always")
+	maxRestarts := fs.Int("max-restarts", 0, "maximum number of restarts of the service (0 means unlimited)")
+	shutdownTimeout := fs.Duration("shutdown-timeout", runner.DefaultShutdownTimeout, "time to wait after SIGTERM before killing a process")
+	strict := fs.Bool("strict", false, "report unknown keys as errors")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return c.usageError(fs, "exactly one file is required")
+	}
+	policy, err := runner.ParseRestartPolicy(*restart)
+	if err != nil {
+		return c.usageError(fs, err.Error())
+	}
+	var opts []apispec.LoadOption
+	if *strict {
+		opts = append(opts, apispec.WithStrict())
+	}
+
+	cfg, err := apispec.LoadFile(fs.Arg(0), opts...)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+		return exitFailure
+	}
+	if *stage != "" {
+		resolved, err := cfg.ForStage(*stage)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return exitFailure
+		}
+		cfg = &resolved.Config
+	}
+	var resolver apispec.SecretResolver
+	if *secrets != "" {
+		store, err := apispec.LoadFileSecretStore(*secrets)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return exitFailure
+		}
+		resolver = store
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	r := runner.New(cfg, runner.Options{
+		Stdout:          c.stdout,
+		Stderr:          c.stderr,
+		Secrets:         resolver,
+		Restart:         policy,
+		MaxRestarts:     *maxRestarts,
+		ShutdownTimeout: *shutdownTimeout,
+		Signals:         signals,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		fmt.Fprintf(c.stderr, "appconfig run: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+func (c *cli) schema(args []string) int {
+	fs := c.flagSet("schema", "[-openapi]")
+	openapi := fs.Bool("openapi", false, "print an OpenAPI 3.1 document instead of a JSON Schema")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return c.usageError(fs, "unexpected arguments")
+	}
+
+	generate := apispec.JSONSchema
+	if *openapi {
+		generate = apispec.OpenAPI
+	}
+	doc, err := generate()
+	if err != nil {
+		fmt.Fprintf(c.stderr, "appconfig schema: %v\n", err)
+		return exitFailure
+	}
+	if _, err := c.stdout.Write(doc); err != nil {
+		fmt.Fprintf(c.stderr, "appconfig schema: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
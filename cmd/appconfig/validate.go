@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// diagnostic は設定ファイルの問題を表す
+type diagnostic struct {
+	// Path は設定ファイル上のパス
+	// 構文エラーなどパスが不明な場合は空
+	Path string `json:"path,omitempty"`
+	// Rule は失敗したバリデーションルール
+	// 未知のキーの場合は `unknown`
+	Rule  string `json:"rule,omitempty"`
+	Param string `json:"param,omitempty"`
+	// Message は利用者向けのメッセージ
+	Message string `json:"message"`
+	// Stage は上書き設定を適用した場合にのみ発生する問題のステージ名
+	Stage  string `json:"stage,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// fileResult は1つの設定ファイルの検証結果を表す
+type fileResult struct {
+	File   string       `json:"file"`
+	Valid  bool         `json:"valid"`
+	Errors []diagnostic `json:"errors,omitempty"`
+}
+
+// validateReport は validate の -o json の出力を表す
+type validateReport struct {
+	Valid bool         `json:"valid"`
+	Files []fileResult `json:"files"`
+}
+
+func (c *cli) validate(args []string) int {
+	fs := c.flagSet("validate", "[-o text|json] [-strict] [-lang ja|en] <file>...")
+	output := fs.String("o", "text", "output format: text or json")
+	strict := fs.Bool("strict", false, "report unknown keys as errors")
+	lang := fs.String("lang", string(apispec.DefaultLocale), "language of the messages: ja or en")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if *output != "text" && *output != "json" {
+		return c.usageError(fs, fmt.Sprintf("unknown output format %q", *output))
+	}
+	locale := apispec.Locale(*lang)
+	if locale != apispec.LocaleJapanese && locale != apispec.LocaleEnglish {
+		return c.usageError(fs, fmt.Sprintf("unknown language %q", *lang))
+	}
+	if fs.NArg() == 0 {
+		return c.usageError(fs, "no files given")
+	}
+	var opts []apispec.LoadOption
+	if *strict {
+		opts = append(opts, apispec.WithStrict())
+	}
+
+	report := validateReport{Valid: true, Files: []fileResult{}}
+	for _, file := range fs.Args() {
+		res := validateFile(file, locale, opts)
+		report.Valid = report.Valid && res.Valid
+		report.Files = append(report.Files, res)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(c.stderr, "appconfig validate: %v\n", err)
+			return exitFailure
+		}
+	} else {
+		for _, res := range report.Files {
+			c.writeText(res)
+		}
+	}
+	if !report.Valid {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// validateFile は file を読み込み、すべてのステージについて上書き設定を適用した設定を検証する
+func validateFile(file string, locale apispec.Locale, opts []apispec.LoadOption) fileResult {
+	res := fileResult{File: file}
+	doc, err := apispec.LoadDocumentFile(file, opts...)
+	if err != nil {
+		res.Errors = diagnostics(err, locale)
+		return res
+	}
+	for i, stage := range doc.Config.Stages {
+		if _, err := doc.Config.ForStage(stage.Name); err != nil {
+			for _, d := range diagnostics(err, locale) {
+				d.Stage = stage.Name
+				if d.Path != "" {
+					pos := stagePosition(doc.Positions, i, d.Path)
+					d.Line, d.Column = pos.Line, pos.Column
+				}
+				res.Errors = append(res.Errors, d)
+			}
+		}
+	}
+	res.Valid = len(res.Errors) == 0
+	return res
+}
+
+// stagePosition は i 番目のステージの上書き設定を適用した設定のパス path の位置を返す
+// 上書き設定に path が記述されている場合はその位置を優先する
+func stagePosition(idx apispec.PositionIndex, i int, path string) apispec.Position {
+	if pos, ok := idx[fmt.Sprintf("stages[%d].overrides.%s", i, path)]; ok {
+		return pos
+	}
+	pos, _ := idx.Lookup(path)
+	return pos
+}
+
+// diagnostics は LoadDocumentFile や ForStage のエラーを diagnostic に変換する
+func diagnostics(err error, locale apispec.Locale) []diagnostic {
+	var loadErr *apispec.LoadError
+	if errors.As(err, &loadErr) {
+		err = loadErr.Err
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var diags []diagnostic
+		for _, e := range joined.Unwrap() {
+			diags = append(diags, diagnostics(e, locale)...)
+		}
+		return diags
+	}
+
+	var verrs apispec.ValidationErrors
+	if errors.As(err, &verrs) {
+		diags := make([]diagnostic, len(verrs))
+		for i, e := range verrs {
+			diags[i] = diagnostic{
+				Path:    e.Path,
+				Rule:    e.Rule,
+				Param:   e.Param,
+				Message: e.LocalizedMessage(locale),
+				Line:    e.Position.Line,
+				Column:  e.Position.Column,
+			}
+		}
+		return diags
+	}
+	var uerrs apispec.UnknownFieldErrors
+	if errors.As(err, &uerrs) {
+		diags := make([]diagnostic, len(uerrs))
+		for i, e := range uerrs {
+			msg := fmt.Sprintf("unknown field %q", e.Key)
+			if e.Suggestion != "" {
+				msg += fmt.Sprintf(", did you mean %q?", e.Suggestion)
+			}
+			diags[i] = diagnostic{
+				Path:    e.Path,
+				Rule:    "unknown",
+				Param:   e.Suggestion,
+				Message: msg,
+				Line:    e.Position.Line,
+				Column:  e.Position.Column,
+			}
+		}
+		return diags
+	}
+	return []diagnostic{{Message: err.Error()}}
+}
+
+// writeText は res を `file:line:column: path: message` 形式で出力する
+func (c *cli) writeText(res fileResult) {
+	if res.Valid {
+		fmt.Fprintf(c.stdout, "%s: ok\n", res.File)
+		return
+	}
+	for _, d := range res.Errors {
+		var b strings.Builder
+		b.WriteString(apispec.Position{File: res.File, Line: d.Line, Column: d.Column}.String())
+		b.WriteString(": ")
+		if d.Path != "" {
+			b.WriteString(d.Path + ": ")
+		}
+		b.WriteString(d.Message)
+		if d.Stage != "" {
+			fmt.Fprintf(&b, " (stage %s)", d.Stage)
+		}
+		fmt.Fprintln(c.stdout, b.String())
+	}
+}
@@ -0,0 +1,136 @@
+package apispec
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validateAppConfig は AppConfig 全体にまたがる整合性を検証する
+// 環境変数のフィールドへの参照は参照先が存在することを検証する
+// 重複はすべて報告され、後に現れたエントリのパスに対して、先に現れたエントリのパスをパラメータとして報告する
+//
+// ブランチ名は同じ文字列の重複に加えて、同じブランチに一致しうるワイルドカードのパターンの組も報告する
+// ブランチ名とパターンの組 (例: `release/1` と `release/*`) は StagesForRef でブランチ名が優先されるため報告しない
+// 正規表現のパターンは重なりを判定できないため、一致するステージが複数ある場合は StagesForRef が *AmbiguousRefError を返す
+func validateAppConfig(sl validator.StructLevel) {
+	c := sl.Current().Interface().(AppConfig)
+
+	releaseNames := make([]string, len(c.Releases))
+	for i, r := range c.Releases {
+		releaseNames[i] = r.Name
+	}
+	reportDuplicates(sl, releaseNames, func(i int) string {
+		return fmt.Sprintf("releases[%d].name", i)
+	})
+
+	stageNames := make([]string, len(c.Stages))
+	branchNames := make([]string, len(c.Stages))
+	for i, s := range c.Stages {
+		stageNames[i] = s.Name
+		if s.Policy.Type == StagePolicyTypeBranch && s.Policy.Branch != nil {
+			branchNames[i] = s.Policy.Branch.Name
+		}
+	}
+	reportDuplicates(sl, stageNames, func(i int) string {
+		return fmt.Sprintf("stages[%d].name", i)
+	})
+	reportDuplicates(sl, branchNames, func(i int) string {
+		return fmt.Sprintf("stages[%d].policy.branch.name", i)
+	})
+	reportOverlappingPatterns(sl, branchNames, func(i int) string {
+		return fmt.Sprintf("stages[%d].policy.branch.name", i)
+	})
+
+	// サービス、ワーカー、ジョブの名前はプロセスの識別子として共通の名前空間を持つ
+	processNames := []string{c.Service.Name}
+	processPaths := []string{"service.name"}
+	for i, w := range c.Workers {
+		processNames = append(processNames, w.Name)
+		processPaths = append(processPaths, fmt.Sprintf("workers[%d].name", i))
+	}
+	for i, j := range c.Jobs {
+		processNames = append(processNames, j.Name)
+		processPaths = append(processPaths, fmt.Sprintf("jobs[%d].name", i))
+	}
+	reportDuplicates(sl, processNames, func(i int) string {
+		return processPaths[i]
+	})
+
+	ports := make([]int, len(c.Service.HTTP))
+	for i, h := range c.Service.HTTP {
+		ports[i] = h.TargetPort
+	}
+	reportDuplicates(sl, ports, func(i int) string {
+		return fmt.Sprintf("service.http[%d].target_port", i)
+	})
+
+	validateServiceProbes(sl, c.Service)
+
+	reportFieldRefs(sl, &c, c.Service.Env, "service.env")
+	for i, r := range c.Releases {
+		reportFieldRefs(sl, &c, r.Env, fmt.Sprintf("releases[%d].env", i))
+	}
+	for i, w := range c.Workers {
+		reportFieldRefs(sl, &c, w.Env, fmt.Sprintf("workers[%d].env", i))
+	}
+	for i, j := range c.Jobs {
+		reportFieldRefs(sl, &c, j.Env, fmt.Sprintf("jobs[%d].env", i))
+	}
+	for i, s := range c.Stages {
+		if s.Overrides != nil && s.Overrides.Service != nil {
+			reportFieldRefs(sl, &c, s.Overrides.Service.Env, fmt.Sprintf("stages[%d].overrides.service.env", i))
+		}
+	}
+}
+
+// reportFieldRefs は env のフィールドへの参照のうち、c で解決できないものを `fieldref` ルールの違反として報告する
+func reportFieldRefs(sl validator.StructLevel, c *AppConfig, env map[string]EnvValue, path string) {
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		ref, ok := env[name].FieldRef()
+		if !ok {
+			continue
+		}
+		if _, err := lookupField(c, ref); err != nil {
+			sl.ReportError(env[name], joinPath(path, name), "", "fieldref", ref)
+		}
+	}
+}
+
+// reportOverlappingPatterns は patterns の中で同じ名前に一致しうるワイルドカードのパターンの組を `refoverlap` ルールの違反として報告する
+// 同じ文字列の重複は reportDuplicates で報告するため除く
+func reportOverlappingPatterns(sl validator.StructLevel, patterns []string, path func(i int) string) {
+	for i, p := range patterns {
+		if p == "" || isLiteralPattern(p) {
+			continue
+		}
+		for j, q := range patterns[:i] {
+			if q == "" || q == p || isLiteralPattern(q) {
+				continue
+			}
+			if refPatternsOverlap(q, p) {
+				sl.ReportError(p, path(i), "", "refoverlap", path(j))
+				break
+			}
+		}
+	}
+}
+
+// reportDuplicates は keys の中で重複している値を `unique` ルールの違反として報告する
+// ゼロ値は未設定として扱い、重複とはみなさない
+func reportDuplicates[K comparable](sl validator.StructLevel, keys []K, path func(i int) string) {
+	var zero K
+	first := make(map[K]int, len(keys))
+	for i, k := range keys {
+		if k == zero {
+			continue
+		}
+		if j, ok := first[k]; ok {
+			sl.ReportError(k, path(i), "", "unique", path(j))
+			continue
+		}
+		first[k] = i
+	}
+}
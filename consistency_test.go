@@ -0,0 +1,112 @@
+package apispec
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAppConfig_Validate_Uniqueness(t *testing.T) {
+	t.Parallel()
+	release := func(name string) ReleaseConfig {
+		return ReleaseConfig{
+			Name:      name,
+			Resources: ResourceConfig{CPU: MustParseQuantity("500m"), Memory: MustParseQuantity("256Mi")},
+			Action:    ReleaseActionConfig{Command: []string{"echo", "deploy"}},
+		}
+	}
+	stage := func(name, branch string) StageConfig {
+		return StageConfig{Name: name, Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: branch}}}
+	}
+	tests := []struct {
+		name   string
+		modify func(c *AppConfig)
+		want   []ValidationError
+	}{
+		{
+			name:   "重複がない場合、エラーにならない",
+			modify: func(c *AppConfig) {},
+		},
+		{
+			name: "Releasesの名前が重複している場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Releases = []ReleaseConfig{release("migrate"), release("seed"), release("migrate")}
+			},
+			want: []ValidationError{
+				{Path: "releases[2].name", Param: "releases[0].name"},
+			},
+		},
+		{
+			name: "Stagesの名前とブランチ名が重複している場合、すべて報告される",
+			modify: func(c *AppConfig) {
+				c.Stages = []StageConfig{stage("production", "main"), stage("staging", "main"), stage("production", "release")}
+			},
+			want: []ValidationError{
+				{Path: "stages[2].name", Param: "stages[0].name"},
+				{Path: "stages[1].policy.branch.name", Param: "stages[0].policy.branch.name"},
+			},
+		},
+		{
+			name: "同じブランチに一致しうるパターンの場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Stages = []StageConfig{stage("production", "release/*"), stage("staging", "main"), stage("qa", "**/1")}
+			},
+			want: []ValidationError{
+				{Path: "stages[2].policy.branch.name", Rule: "refoverlap", Param: "stages[0].policy.branch.name"},
+			},
+		},
+		{
+			name: "ブランチ名とパターン、重ならないパターンの場合、エラーにならない",
+			modify: func(c *AppConfig) {
+				c.Stages = []StageConfig{stage("production", "release/*"), stage("hotfix", "release/1"), stage("feature", "feature/*"), stage("preview", "/^preview-/")}
+			},
+		},
+		{
+			name: "TargetPortが重複している場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Service.HTTP = []ServiceHTTPConfig{{TargetPort: 8080}, {TargetPort: 8080, ForceHTTPS: true}}
+			},
+			want: []ValidationError{
+				{Path: "service.http[1].target_port", Param: "service.http[0].target_port"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := AppConfig{
+				Version:  LatestVersion,
+				AppName:  "myapp",
+				Build:    BuildConfig{Image: "myapp:latest"},
+				Releases: []ReleaseConfig{release("migrate")},
+				Service:  ServiceConfig{Name: "web", Command: []string{"npm", "start"}},
+			}
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Validate() = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if want.Rule == "" {
+					want.Rule = "unique"
+				}
+				if errs[i].Path != want.Path || errs[i].Param != want.Param || errs[i].Rule != want.Rule {
+					t.Errorf("errs[%d] = %+v, want path %q param %q rule %q", i, errs[i], want.Path, want.Param, want.Rule)
+				}
+				if msg := "値が" + want.Param + "と重複しています"; want.Rule == "unique" && errs[i].Message != msg {
+					t.Errorf("errs[%d].Message = %q, want %q", i, errs[i].Message, msg)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package apispec
+
+import "path"
+
+const (
+	// DefaultStageName はステージが定義されていない場合に作成されるステージの名前
+	DefaultStageName = "production"
+	// DefaultBranchName はデフォルトのステージがデプロイ対象とするブランチの名前
+	DefaultBranchName = "main"
+)
+
+// DefaultStage はステージが定義されていない場合に作成されるステージを返す
+// DefaultBranchName へのプッシュでデプロイされる
+func DefaultStage() StageConfig {
+	return StageConfig{
+		Name: DefaultStageName,
+		Policy: StagePolicyConfig{
+			Type:   StagePolicyTypeBranch,
+			Branch: &BranchConfig{Name: DefaultBranchName},
+		},
+	}
+}
+
+// ApplyDefaults は省略された設定にデフォルト値を設定する
+// 既に値が設定されているフィールドは変更しない
+//
+//   - Stages が空の場合は DefaultStage を追加する
+//   - Policy.Type が省略され Policy.Branch が設定されている場合は `branch`、Policy.Tag が設定されている場合は `tag` とする
+//   - Build.DockerContext が省略され Build.Dockerfile が設定されている場合は Dockerfile のあるディレクトリとする
+//   - Jobs の ConcurrencyPolicy が省略されている場合は `Allow` とする
+func (c *AppConfig) ApplyDefaults() {
+	if c.Build.Dockerfile != "" && c.Build.DockerContext == "" {
+		c.Build.DockerContext = path.Dir(c.Build.Dockerfile)
+	}
+	for i := range c.Jobs {
+		if c.Jobs[i].ConcurrencyPolicy == "" {
+			c.Jobs[i].ConcurrencyPolicy = ConcurrencyPolicyAllow
+		}
+	}
+	if len(c.Stages) == 0 {
+		c.Stages = []StageConfig{DefaultStage()}
+	}
+	for i := range c.Stages {
+		p := &c.Stages[i].Policy
+		switch {
+		case p.Type != "":
+		case p.Branch != nil:
+			p.Type = StagePolicyTypeBranch
+		case p.Tag != nil:
+			p.Type = StagePolicyTypeTag
+		}
+	}
+}
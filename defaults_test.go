@@ -0,0 +1,147 @@
+package apispec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppConfig_ApplyDefaults(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		stages []StageConfig
+		want   []StageConfig
+	}{
+		{
+			name:   "Stagesが定義されていない場合、productionステージが作成される",
+			stages: nil,
+			want: []StageConfig{
+				{
+					Name: "production",
+					Policy: StagePolicyConfig{
+						Type:   "branch",
+						Branch: &BranchConfig{Name: "main"},
+					},
+				},
+			},
+		},
+		{
+			name: "Stagesが定義されている場合、productionステージは作成されない",
+			stages: []StageConfig{
+				{Name: "staging", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "develop"}}},
+			},
+			want: []StageConfig{
+				{Name: "staging", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "develop"}}},
+			},
+		},
+		{
+			name: "Policy.Typeが省略されTagが設定されている場合、tagになる",
+			stages: []StageConfig{
+				{Name: "production", Policy: StagePolicyConfig{Tag: &TagConfig{Pattern: "v*"}}},
+			},
+			want: []StageConfig{
+				{Name: "production", Policy: StagePolicyConfig{Type: "tag", Tag: &TagConfig{Pattern: "v*"}}},
+			},
+		},
+		{
+			name: "Policy.Typeが省略されBranchが設定されている場合、branchになる",
+			stages: []StageConfig{
+				{Name: "staging", Policy: StagePolicyConfig{Branch: &BranchConfig{Name: "develop"}}},
+			},
+			want: []StageConfig{
+				{Name: "staging", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "develop"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := AppConfig{Stages: tt.stages}
+			cfg.ApplyDefaults()
+			if !reflect.DeepEqual(cfg.Stages, tt.want) {
+				t.Errorf("Stages = %+v, want %+v", cfg.Stages, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppConfig_ApplyDefaults_DockerContext(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		build BuildConfig
+		want  string
+	}{
+		{
+			name:  "DockerContextが省略された場合、Dockerfileのディレクトリになる",
+			build: BuildConfig{Dockerfile: "docker/web/Dockerfile"},
+			want:  "docker/web",
+		},
+		{
+			name:  "Dockerfileがルートにある場合、カレントディレクトリになる",
+			build: BuildConfig{Dockerfile: "Dockerfile"},
+			want:  ".",
+		},
+		{
+			name:  "DockerContextが設定されている場合、変更されない",
+			build: BuildConfig{Dockerfile: "docker/Dockerfile", DockerContext: "."},
+			want:  ".",
+		},
+		{
+			name:  "Imageが設定されている場合、DockerContextは設定されない",
+			build: BuildConfig{Image: "myapp:latest"},
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := AppConfig{Build: tt.build}
+			cfg.ApplyDefaults()
+			if cfg.Build.DockerContext != tt.want {
+				t.Errorf("Build.DockerContext = %q, want %q", cfg.Build.DockerContext, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppConfig_ApplyDefaults_Idempotent(t *testing.T) {
+	t.Parallel()
+	cfg := AppConfig{}
+	cfg.ApplyDefaults()
+	first := append([]StageConfig(nil), cfg.Stages...)
+	cfg.ApplyDefaults()
+	if !reflect.DeepEqual(cfg.Stages, first) {
+		t.Errorf("Stages = %+v, want %+v", cfg.Stages, first)
+	}
+}
+
+func TestLoad_AppliesDefaults(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFile("testdata/valid.yaml")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if want := []StageConfig{DefaultStage()}; !reflect.DeepEqual(cfg.Stages, want) {
+		t.Errorf("Stages = %+v, want %+v", cfg.Stages, want)
+	}
+
+	// デフォルト値の適用後にバリデーションが行われる
+	input := `
+app_name: myapp
+build: {image: myapp:latest}
+releases: []
+service: {name: web, command: [npm, start]}
+stages: [{name: staging, policy: {branch: {name: develop}}}]
+`
+	cfg, err = Load(strings.NewReader(input), FormatYAML)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Stages[0].Policy.Type; got != StagePolicyTypeBranch {
+		t.Errorf("Stages[0].Policy.Type = %q, want %q", got, StagePolicyTypeBranch)
+	}
+}
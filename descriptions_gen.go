@@ -0,0 +1,131 @@
+// Code generated by gendesc. DO NOT EDIT.
+
+package apispec
+
+// typeDescriptions は型のコメントを表す
+var typeDescriptions = map[string]string{
+	"BranchConfig":                "BranchConfig はブランチポリシーの設定を表す",
+	"BuildConfig":                 "BuildConfig はアプリケーションのビルド設定を表す\nImage と Dockerfile のどちらか一方のみを指定する",
+	"BuildOverridesConfig":        "BuildOverridesConfig はビルド設定の上書きを表す",
+	"CronJobConfig":               "CronJobConfig は定期的に実行するジョブの設定を表す",
+	"Duration":                    "Duration は `30s` や `1h30m` のような時間の長さを表す\n形式は time.ParseDuration と同じ\n\n設定ファイルから読み込んだ値が不正な場合はデコード時にはエラーにならず、\n`duration` ルールのバリデーションエラーとして報告される",
+	"EnvValue":                    "EnvValue は環境変数の値を表す\n\n  - `secret://<name>/<key>` は名前付きシークレットの値を参照する\n  - `field://<path>` は設定の他のフィールド (例: `field://app_name`, `field://service.http[0].target_port`) を参照する\n  - それ以外はそのままの値として扱う",
+	"HealthcheckConfig":           "HealthcheckConfig はサービスのヘルスチェック設定を表す\nHTTP, Process, TCP, GRPC のいずれか1つのみを指定する",
+	"HealthcheckGRPCConfig":       "HealthcheckGRPCConfig はgRPCヘルスチェックの設定を表す\ngRPC Health Checking Protocol の応答が `SERVING` の場合を正常とみなす",
+	"HealthcheckHTTPConfig":       "HealthcheckHTTPConfig はHTTPヘルスチェックの設定を表す\n2xx または 3xx のステータスコードを正常とみなす",
+	"HealthcheckProcessConfig":    "HealthcheckProcessConfig はプロセスヘルスチェックの設定を表す\nコマンドが終了コード0で終了した場合を正常とみなす",
+	"HealthcheckTCPConfig":        "HealthcheckTCPConfig はTCPヘルスチェックの設定を表す\nポートへの接続に成功した場合を正常とみなす",
+	"MachineConfig":               "MachineConfig はサービスのマシン設定を表す",
+	"MachineOverridesConfig":      "MachineOverridesConfig はマシン設定の上書きを表す",
+	"ProbesConfig":                "ProbesConfig はサービスの役割ごとのヘルスチェック設定を表す",
+	"Quantity":                    "Quantity はKubernetes形式のリソース量 (例: `500m`, `2`, `256Mi`, `1G`) を表す\n内部ではミリ単位の整数として保持するため、1/1000 未満の端数は切り上げられる\n同じ理由で表せる値は約9.2P (8Pi より少し大きい値) までに限られ、接尾辞 E と Ei は使えない\n\n設定ファイルから読み込んだ値が不正な場合はデコード時にはエラーにならず、\n`quantity` ルールのバリデーションエラーとして報告される",
+	"ReleaseActionConfig":         "ReleaseActionConfig はリリースアクションの設定を表す",
+	"ReleaseConfig":               "ReleaseConfig はアプリケーションのリリース設定を表す",
+	"ResourceConfig":              "ResourceConfig はリソース設定を表す",
+	"ServiceConfig":               "ServiceConfig はアプリケーションのサービス設定を表す",
+	"ServiceHTTPConfig":           "ServiceHTTPConfig はサービスのHTTP設定を表す",
+	"ServiceMetricConfig":         "ServiceMetricConfig はサービスのスケーリングメトリクスの設定を表す",
+	"ServiceOverridesConfig":      "ServiceOverridesConfig はサービス設定の上書きを表す",
+	"ServiceScaleConfig":          "ServiceScaleConfig はサービスのスケーリング設定を表す",
+	"ServiceScaleOverridesConfig": "ServiceScaleOverridesConfig はスケーリング設定の上書きを表す",
+	"StageConfig":                 "StageConfig はアプリケーションのステージ設定を表す",
+	"StageOverridesConfig":        "StageOverridesConfig はステージごとに上書きする設定を表す",
+	"StagePolicyConfig":           "StagePolicyConfig はステージのポリシー設定を表す",
+	"TagConfig":                   "TagConfig はタグポリシーの設定を表す",
+	"WorkerConfig":                "WorkerConfig はバックグラウンドプロセスの設定を表す\nServiceConfig と異なり、HTTPリクエストは受け付けない",
+}
+
+// fieldDescriptions は `型名.フィールド名` からフィールドのコメントへの対応を表す
+var fieldDescriptions = map[string]string{
+	"AppConfig.AppName":                    "AppName はアプリケーションの名前",
+	"AppConfig.Build":                      "Build はアプリケーションのビルド設定",
+	"AppConfig.Jobs":                       "Jobs は定期的に実行するジョブの設定",
+	"AppConfig.Releases":                   "Releases はアプリケーションのリリース設定",
+	"AppConfig.Service":                    "Service はアプリケーションのサービス設定",
+	"AppConfig.Stages":                     "Stages はアプリケーションのステージ設定\n何も定義されていない場合は、デフォルトで `production` ステージが作成される (ApplyDefaults を参照)",
+	"AppConfig.Version":                    "Version は設定ファイルの形式のバージョン\nLatestVersion を指定する。古い形式の設定ファイルは読み込む際に LatestVersion の形式に移行される",
+	"AppConfig.Workers":                    "Workers はHTTPリクエストを受け付けないバックグラウンドプロセスの設定",
+	"BranchConfig.Name":                    "Name は対象のブランチ名\n`release/*` のような glob パターンや、`/^release\\/.+$/` のようにスラッシュで囲んだ正規表現も指定できる",
+	"BuildConfig.BuildArgs":                "BuildArgs はDockerビルド時に使用するビルド引数\nImage を指定した場合は指定できない (空のマップは指定していないものとして扱う)\nビルド引数はイメージに残るため、シークレットへの参照 (`secret://`) は指定できない",
+	"BuildConfig.DockerContext":            "DockerContextはDockerイメージをビルドするためのコンテキストのパス\n省略した場合は Dockerfile のあるディレクトリが使用される\nImage を指定した場合は指定できない",
+	"BuildConfig.Dockerfile":               "Dockerfile はDockerイメージをビルドするためのDockerfileのパス",
+	"BuildConfig.Image":                    "Image はイメージビルドを行わず、既存のイメージを使用する場合に指定する",
+	"BuildOverridesConfig.BuildArgs":       "BuildArgs はビルド引数の上書き\nキーごとにマージされ、同じキーはステージの値が優先される",
+	"CronJobConfig.Command":                "Command はジョブで実行するコマンド",
+	"CronJobConfig.ConcurrencyPolicy":      "ConcurrencyPolicy は前回の実行が終わる前に次の実行時刻になった場合の動作\n`Allow`, `Forbid`, `Replace` のいずれかで、省略した場合は `Allow`",
+	"CronJobConfig.Env":                    "Env はジョブに渡す環境変数\n値の形式は EnvValue を参照",
+	"CronJobConfig.Name":                   "Name はジョブの名前\nサービスやワーカーの名前と重複してはならない",
+	"CronJobConfig.Resources":              "Resources はジョブのリソース設定",
+	"CronJobConfig.Schedule":               "Schedule はジョブを実行するスケジュール\n5フィールドの cron 式 (例: `0 3 * * *`) または `@hourly` や `@every 1h` のような記述子\n`@every` の間隔は1時間または1日を割り切れなければならない (例: `@every 15m`, `@every 6h`)",
+	"CronJobConfig.Timeout":                "Timeout はジョブの実行時間の上限 (例: `30m`)\n省略した場合は上限なし",
+	"HealthcheckConfig.FailureThreshold":   "FailureThreshold は異常とみなすまでに必要な連続した失敗の回数\n省略した場合は DefaultHealthcheckFailureThreshold",
+	"HealthcheckConfig.GRPC":               "GRPC はgRPCヘルスチェックの設定",
+	"HealthcheckConfig.HTTP":               "HTTP はHTTPヘルスチェックの設定",
+	"HealthcheckConfig.InitialDelay":       "InitialDelay はサービスの起動から最初のヘルスチェックまでの待ち時間 (例: `5s`)\n省略した場合は待たずに開始する",
+	"HealthcheckConfig.Interval":           "Interval はヘルスチェックの間隔\n0より大きくなければならず、省略した場合は DefaultHealthcheckInterval",
+	"HealthcheckConfig.Process":            "Process はプロセスヘルスチェックの設定",
+	"HealthcheckConfig.SuccessThreshold":   "SuccessThreshold は失敗の後、正常とみなすまでに必要な連続した成功の回数\n省略した場合は DefaultHealthcheckSuccessThreshold",
+	"HealthcheckConfig.TCP":                "TCP はTCPヘルスチェックの設定",
+	"HealthcheckConfig.Timeout":            "Timeout は1回のヘルスチェックの制限時間\n0より大きくなければならず、省略した場合は DefaultHealthcheckTimeout",
+	"HealthcheckGRPCConfig.Port":           "Port はヘルスチェックの対象のポート\nservice.http の target_port のいずれかでなければならず、省略した場合は最初の target_port",
+	"HealthcheckGRPCConfig.Service":        "Service は Health Checking Protocol のリクエストに指定するサービス名\n省略した場合はサーバー全体の状態を問い合わせる",
+	"HealthcheckHTTPConfig.Headers":        "Headers はヘルスチェックのリクエストに付与するヘッダー",
+	"HealthcheckHTTPConfig.Path":           "Path はヘルスチェックのエンドポイントパス",
+	"HealthcheckHTTPConfig.Port":           "Port はヘルスチェックの対象のポート\nservice.http の target_port のいずれかでなければならず、省略した場合は最初の target_port",
+	"HealthcheckProcessConfig.Command":     "Command はヘルスチェックに使用するコマンド",
+	"HealthcheckTCPConfig.Port":            "Port はヘルスチェックの対象のポート\nservice.http の target_port のいずれかでなければならず、省略した場合は最初の target_port",
+	"MachineConfig.CPU":                    "CPU はマシンのCPUリソースの量 (例: `500m`, `2`)",
+	"MachineConfig.Flavor":                 "Flavor はマシンのフレーバー",
+	"MachineConfig.Memory":                 "Memory はマシンのメモリリソースの量 (例: `256Mi`, `1G`)",
+	"MachineOverridesConfig.CPU":           "CPU はマシンのCPUリソースの量の上書き",
+	"MachineOverridesConfig.Flavor":        "Flavor はマシンのフレーバーの上書き",
+	"MachineOverridesConfig.Memory":        "Memory はマシンのメモリリソースの量の上書き",
+	"ProbesConfig.Liveness":                "Liveness はサービスが動作し続けているかどうかを判定するヘルスチェック\n失敗した場合はサービスを再起動する",
+	"ProbesConfig.Readiness":               "Readiness はサービスがリクエストを受け付けられるかどうかを判定するヘルスチェック\nServiceConfig.Healthcheck と同時に指定することはできない",
+	"ProbesConfig.Startup":                 "Startup はサービスの起動が完了したかどうかを判定するヘルスチェック\n成功するまで Readiness と Liveness は実行されない",
+	"ReleaseActionConfig.Command":          "Command はリリース時に実行されるコマンド",
+	"ReleaseConfig.Action":                 "Action はリリースのアクション設定",
+	"ReleaseConfig.Env":                    "Env はリリースのコマンドに渡す環境変数\n値の形式は EnvValue を参照",
+	"ReleaseConfig.Name":                   "Name はリリースの名前",
+	"ReleaseConfig.Resources":              "Resources はリリースのリソース設定",
+	"ResourceConfig.CPU":                   "CPU はCPUリソースの量 (例: `500m`, `2`)",
+	"ResourceConfig.Memory":                "Memory はメモリリソースの量 (例: `256Mi`, `1G`)",
+	"ServiceConfig.Command":                "Command はサービスの起動コマンド",
+	"ServiceConfig.Env":                    "Env はサービスに渡す環境変数\n値の形式は EnvValue を参照",
+	"ServiceConfig.HTTP":                   "HTTP はサービスのHTTP設定",
+	"ServiceConfig.Healthcheck":            "Healthcheck はサービスのヘルスチェック設定\nProbes.Readiness と同じ役割を持ち、同時に指定することはできない",
+	"ServiceConfig.MachineConfig":          "MachineConfig はサービスのマシン設定",
+	"ServiceConfig.Name":                   "Name はサービスの名前",
+	"ServiceConfig.Probes":                 "Probes はサービスの役割ごとのヘルスチェック設定",
+	"ServiceConfig.Scale":                  "Scale はサービスのスケーリング設定",
+	"ServiceHTTPConfig.ForceHTTPS":         "ForceHTTPS はHTTPリクエストをHTTPSにリダイレクトするかどうか",
+	"ServiceHTTPConfig.TargetPort":         "TargetPort はサービスがリッスンするポート",
+	"ServiceMetricConfig.Threshold":        "Threshold はスケーリングの閾値\n`cpu` と `memory` の場合は使用率 (1〜100%)、`rps` の場合は1インスタンスあたりの秒間リクエスト数、\n`concurrency` の場合は1インスタンスあたりの同時リクエスト数",
+	"ServiceMetricConfig.Type":             "Type はメトリクスの種類\n`cpu`, `memory`, `rps`, `concurrency` のいずれか",
+	"ServiceOverridesConfig.Command":       "Command はサービスの起動コマンドの上書き\n指定した場合はコマンド全体を置き換える",
+	"ServiceOverridesConfig.Env":           "Env はサービスの環境変数の上書き\nキーごとにマージされ、同じキーはステージの値が優先される",
+	"ServiceOverridesConfig.MachineConfig": "MachineConfig はマシン設定の上書き\n指定したフィールドのみを上書きする",
+	"ServiceOverridesConfig.Scale":         "Scale はスケーリング設定の上書き\n指定したフィールドのみを上書きする",
+	"ServiceScaleConfig.Max":               "Max はサービスの最大インスタンス数\nMin 以上でなければならない",
+	"ServiceScaleConfig.Metric":            "Metric はスケーリングに使用するメトリクスの設定",
+	"ServiceScaleConfig.Min":               "Min はサービスの最小インスタンス数\n0 を指定した場合は負荷がない間インスタンスを停止する (scale-to-zero)\n省略した場合に意図せず scale-to-zero にならないよう、必ず指定しなければならない",
+	"ServiceScaleOverridesConfig.Max":      "Max はサービスの最大インスタンス数の上書き",
+	"ServiceScaleOverridesConfig.Metric":   "Metric はスケーリングに使用するメトリクスの設定の上書き\n指定した場合はメトリクスの設定全体を置き換える",
+	"ServiceScaleOverridesConfig.Min":      "Min はサービスの最小インスタンス数の上書き",
+	"StageConfig.Name":                     "Name はステージの名前",
+	"StageConfig.Overrides":                "Overrides はステージで上書きする設定\n上書きの規則は AppConfig.ForStage を参照",
+	"StageConfig.Policy":                   "Policy はステージのポリシー設定",
+	"StageOverridesConfig.Build":           "Build はビルド設定の上書き",
+	"StageOverridesConfig.Releases":        "Releases はリリース設定の上書き\n指定した場合はリリース設定全体を置き換える",
+	"StageOverridesConfig.Service":         "Service はサービス設定の上書き",
+	"StagePolicyConfig.Branch":             "Branch はブランチポリシーの設定\nType が `branch` の場合に必須",
+	"StagePolicyConfig.Tag":                "Tag はタグポリシーの設定\nType が `tag` の場合に必須",
+	"StagePolicyConfig.Type":               "Type はポリシーの種類\n`branch`, `tag`, `pull_request`, `manual` のいずれか",
+	"TagConfig.Pattern":                    "Pattern は対象のタグ名\nBranchConfig.Name と同様に glob パターンや正規表現を指定できる",
+	"TagConfig.Semver":                     "Semver はセマンティックバージョン (例: `v1.2.3`) のタグのみを対象とするかどうか",
+	"WorkerConfig.Command":                 "Command はワーカーの起動コマンド",
+	"WorkerConfig.Env":                     "Env はワーカーに渡す環境変数\n値の形式は EnvValue を参照",
+	"WorkerConfig.MachineConfig":           "MachineConfig はワーカーのマシン設定",
+	"WorkerConfig.Name":                    "Name はワーカーの名前\nサービスやジョブの名前と重複してはならない",
+	"WorkerConfig.Scale":                   "Scale はワーカーのスケーリング設定",
+}
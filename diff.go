@@ -0,0 +1,221 @@
+package apispec
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ChangeKind は変更の種類を表す
+type ChangeKind string
+
+const (
+	// ChangeAdded はブロック、マップのキー、またはリストの要素が追加されたことを表す
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved はブロック、マップのキー、またはリストの要素が削除されたことを表す
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified は値が変更されたことを表す
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change は2つの AppConfig の間の1つの変更を表す
+type Change struct {
+	// Path は設定ファイル上のパス (例: `releases[name=migrate].resources.cpu`)
+	// 名前などで識別するリストの要素は `[キー=値]` で表す
+	Path string `json:"path"`
+	// Kind は変更の種類
+	Kind ChangeKind `json:"kind"`
+	// Old は変更前の値
+	// ChangeAdded の場合は nil
+	Old any `json:"old,omitempty"`
+	// New は変更後の値
+	// ChangeRemoved の場合は nil
+	New any `json:"new,omitempty"`
+}
+
+// listIdentity はリストの要素を識別する方法を表す
+type listIdentity struct {
+	// key は要素を識別するキー
+	key string
+	// ordered は要素の順序に意味があるかどうか
+	ordered bool
+}
+
+// listIdentities は要素を位置ではなくキーで識別するリストの要素の型を表す
+// これ以外のリストは全体を1つの値として比較する
+var listIdentities = map[reflect.Type]listIdentity{
+	// リリースは宣言された順に実行される
+	reflect.TypeFor[ReleaseConfig]():     {key: "name", ordered: true},
+	reflect.TypeFor[WorkerConfig]():      {key: "name"},
+	reflect.TypeFor[CronJobConfig]():     {key: "name"},
+	reflect.TypeFor[StageConfig]():       {key: "name"},
+	reflect.TypeFor[ServiceHTTPConfig](): {key: "target_port"},
+}
+
+// Diff は old から new への変更を設定ファイル上のパスごとに返す
+// 変更がない場合は空のスライスを返す
+//
+//   - releases, stages, workers, jobs の要素は名前で、service.http の要素は target_port で対応付ける
+//     releases の順序が変わった場合は releases の名前の一覧の変更として報告する
+//   - マップはキーごとに比較する
+//   - 上記以外のリスト (command など) は全体を1つの値として比較する
+//   - リソース量と時間の長さは表記ではなく値で比較する (例: `1000m` と `1` は等しい)
+//
+// デフォルト値は適用しないため、LoadFile で読み込んだ設定どうしを比較することを想定している
+func Diff(old, new *AppConfig) []Change {
+	d := &differ{changes: []Change{}}
+	d.diff("", reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem())
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(path string, kind ChangeKind, old, new reflect.Value) {
+	c := Change{Path: path, Kind: kind}
+	if old.IsValid() {
+		c.Old = old.Interface()
+	}
+	if new.IsValid() {
+		c.New = new.Interface()
+	}
+	d.changes = append(d.changes, c)
+}
+
+// diff は同じ型の値 a と b を比較する
+func (d *differ) diff(path string, a, b reflect.Value) {
+	t := a.Type()
+	if isOpaque(t) {
+		if !equalValue(a, b) {
+			d.add(path, ChangeModified, a, b)
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			d.add(path, ChangeAdded, reflect.Value{}, b.Elem())
+		case b.IsNil():
+			d.add(path, ChangeRemoved, a.Elem(), reflect.Value{})
+		default:
+			d.diff(path, a.Elem(), b.Elem())
+		}
+	case reflect.Struct:
+		for _, f := range structFields(t) {
+			i := f.Field.Index
+			d.diff(joinPath(path, f.Name), a.FieldByIndex(i), b.FieldByIndex(i))
+		}
+	case reflect.Map:
+		d.diffMap(path, a, b)
+	case reflect.Slice:
+		if id, ok := listIdentities[t.Elem()]; ok && d.diffList(path, id, a, b) {
+			return
+		}
+		if !equalValue(a, b) {
+			d.add(path, ChangeModified, a, b)
+		}
+	default:
+		if !equalValue(a, b) {
+			d.add(path, ChangeModified, a, b)
+		}
+	}
+}
+
+func (d *differ) diffMap(path string, a, b reflect.Value) {
+	keys := map[string]reflect.Value{}
+	for _, m := range []reflect.Value{a, b} {
+		for _, k := range m.MapKeys() {
+			keys[k.String()] = k
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		k := keys[name]
+		av, bv := a.MapIndex(k), b.MapIndex(k)
+		p := joinPath(path, name)
+		switch {
+		case !av.IsValid():
+			d.add(p, ChangeAdded, reflect.Value{}, bv)
+		case !bv.IsValid():
+			d.add(p, ChangeRemoved, av, reflect.Value{})
+		default:
+			d.diff(p, av, bv)
+		}
+	}
+}
+
+// diffList は a と b の要素を id のキーで対応付けて比較する
+// キーが重複している場合は対応付けられないため false を返す
+func (d *differ) diffList(path string, id listIdentity, a, b reflect.Value) bool {
+	aKeys, ok := listKeys(id, a)
+	if !ok {
+		return false
+	}
+	bKeys, ok := listKeys(id, b)
+	if !ok {
+		return false
+	}
+	for i, k := range aKeys {
+		p := fmt.Sprintf("%s[%s=%s]", path, id.key, k)
+		if j := slices.Index(bKeys, k); j >= 0 {
+			d.diff(p, a.Index(i), b.Index(j))
+		} else {
+			d.add(p, ChangeRemoved, a.Index(i), reflect.Value{})
+		}
+	}
+	for j, k := range bKeys {
+		if !slices.Contains(aKeys, k) {
+			d.add(fmt.Sprintf("%s[%s=%s]", path, id.key, k), ChangeAdded, reflect.Value{}, b.Index(j))
+		}
+	}
+	if id.ordered {
+		// 両方に存在する要素の順序のみを比較する
+		aOrder := slices.DeleteFunc(slices.Clone(aKeys), func(k string) bool { return !slices.Contains(bKeys, k) })
+		bOrder := slices.DeleteFunc(slices.Clone(bKeys), func(k string) bool { return !slices.Contains(aKeys, k) })
+		if !slices.Equal(aOrder, bOrder) {
+			d.changes = append(d.changes, Change{Path: path, Kind: ChangeModified, Old: aKeys, New: bKeys})
+		}
+	}
+	return true
+}
+
+// listKeys は list の各要素の id のキーの値を返す
+// キーが重複している場合は false を返す
+func listKeys(id listIdentity, list reflect.Value) ([]string, bool) {
+	t := list.Type().Elem()
+	idx := slices.IndexFunc(structFields(t), func(f structField) bool { return f.Name == id.key })
+	field := structFields(t)[idx].Field.Index
+	keys := make([]string, list.Len())
+	for i := range list.Len() {
+		k := fmt.Sprint(list.Index(i).FieldByIndex(field).Interface())
+		if slices.Contains(keys[:i], k) {
+			return nil, false
+		}
+		keys[i] = k
+	}
+	return keys, true
+}
+
+// equalValue は a と b が等しいかどうかを返す
+// 空のリストやマップは nil と等しいものとして扱う
+func equalValue(a, b reflect.Value) bool {
+	switch av := a.Interface().(type) {
+	case Quantity:
+		bv := b.Interface().(Quantity)
+		return av.IsSet() == bv.IsSet() && av.Cmp(bv) == 0
+	case Duration:
+		bv := b.Interface().(Duration)
+		return av.IsSet() == bv.IsSet() && av.Std() == bv.Std()
+	}
+	if k := a.Kind(); (k == reflect.Slice || k == reflect.Map) && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,152 @@
+package apispec
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile("testdata/stages.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := string(b)
+	const seed = `  - name: seed
+    resources:
+      cpu: 100m
+      memory: 64Mi
+    action:
+      command: ["bin/seed"]
+`
+
+	tests := []struct {
+		name    string
+		replace []string
+		want    []string
+	}{
+		{
+			name: "同じ設定の場合、変更はない",
+		},
+		{
+			name:    "リソース量は表記ではなく値で比較する",
+			replace: []string{"cpu: \"1\"", "cpu: 1000m", "memory: 1Gi", "memory: 1024Mi"},
+		},
+		{
+			name:    "値の変更をパスとともに報告する",
+			replace: []string{"max: 10", "max: 20", "cpu: 500m", "cpu: \"1\""},
+			want: []string{
+				"modified releases[name=migrate].resources.cpu",
+				"modified service.scale.max",
+			},
+		},
+		{
+			name:    "コマンドはリスト全体を1つの値として比較する",
+			replace: []string{`command: ["npm", "start"]`, `command: ["npm", "run", "serve"]`},
+			want:    []string{"modified service.command"},
+		},
+		{
+			name:    "マップはキーごとに比較する",
+			replace: []string{"    LOG_FORMAT: json\n", "    LOG_FORMAT: logfmt\n    TZ: UTC\n", "    NODE_ENV: production\n", ""},
+			want: []string{
+				"removed build.build_args.NODE_ENV",
+				"modified service.env.LOG_FORMAT",
+				"added service.env.TZ",
+			},
+		},
+		{
+			name:    "リリースは名前で対応付け、追加を報告する",
+			replace: []string{"service:\n", seed + "service:\n"},
+			want:    []string{"added releases[name=seed]"},
+		},
+		{
+			name:    "ステージは名前で対応付け、上書き設定の変更を報告する",
+			replace: []string{"name: develop", "name: staging", "min: 0", "min: 1"},
+			want: []string{
+				"modified stages[name=staging].policy.branch.name",
+				"modified stages[name=staging].overrides.service.scale.min",
+			},
+		},
+		{
+			name:    "ブロックの削除を報告する",
+			replace: []string{"  machine_config:\n    cpu: \"1\"\n    memory: 1Gi\n", ""},
+			want:    []string{"removed service.machine_config"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			changed := base
+			for i := 0; i < len(tt.replace); i += 2 {
+				if !strings.Contains(changed, tt.replace[i]) {
+					t.Fatalf("testdata/stages.yaml does not contain %q", tt.replace[i])
+				}
+				changed = strings.Replace(changed, tt.replace[i], tt.replace[i+1], 1)
+			}
+			old, err := Load(strings.NewReader(base), FormatYAML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			new, err := Load(strings.NewReader(changed), FormatYAML)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			got := []string{}
+			for _, c := range Diff(old, new) {
+				got = append(got, string(c.Kind)+" "+c.Path)
+			}
+			if tt.want == nil {
+				tt.want = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff_Values(t *testing.T) {
+	t.Parallel()
+	resources := ResourceConfig{CPU: MustParseQuantity("100m"), Memory: MustParseQuantity("64Mi")}
+	migrate := ReleaseConfig{Name: "migrate", Resources: resources, Action: ReleaseActionConfig{Command: []string{"bin/migrate"}}}
+	seed := ReleaseConfig{Name: "seed", Resources: resources, Action: ReleaseActionConfig{Command: []string{"bin/seed"}}}
+	old := &AppConfig{
+		Version:  LatestVersion,
+		AppName:  "myapp",
+		Releases: []ReleaseConfig{migrate, seed},
+		Service: ServiceConfig{
+			Name:  "web",
+			HTTP:  []ServiceHTTPConfig{{TargetPort: 8080}, {TargetPort: 9090}},
+			Scale: &ServiceScaleConfig{Min: ptr(1), Max: 2, Metric: ServiceMetricConfig{Type: "cpu", Threshold: 80}},
+		},
+	}
+	new := &AppConfig{
+		Version:  LatestVersion,
+		AppName:  "myapp",
+		Releases: []ReleaseConfig{seed, migrate},
+		Service: ServiceConfig{
+			Name: "web",
+			HTTP: []ServiceHTTPConfig{{TargetPort: 9090, ForceHTTPS: true}, {TargetPort: 8080}},
+		},
+	}
+
+	got, err := json.Marshal(Diff(old, new))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal([]Change{
+		{Path: "releases", Kind: ChangeModified, Old: []string{"migrate", "seed"}, New: []string{"seed", "migrate"}},
+		{Path: "service.http[target_port=9090].force_https", Kind: ChangeModified, Old: false, New: true},
+		{Path: "service.scale", Kind: ChangeRemoved, Old: old.Service.Scale},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Diff() = %s, want %s", got, want)
+	}
+}
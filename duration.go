@@ -0,0 +1,182 @@
+package apispec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration は `30s` や `1h30m` のような時間の長さを表す
+// 形式は time.ParseDuration と同じ
+//
+// 設定ファイルから読み込んだ値が不正な場合はデコード時にはエラーにならず、
+// `duration` ルールのバリデーションエラーとして報告される
+type Duration struct {
+	d     time.Duration
+	valid bool
+
+	// raw は解析に失敗した設定ファイル上の値
+	raw string
+}
+
+// durationPattern は設定ファイルに記述できる時間の長さの形式を表す
+// 負の値は許可しないため、符号は記述できない
+var durationPattern = regexp.MustCompile(`^(0|([0-9]+(\.[0-9]+)?` + durationUnit + `)+)$`)
+
+// durationUnit は時間の長さの単位を表す
+const durationUnit = `(ns|us|µs|ms|s|m|h)`
+
+// positiveDurationPattern は0より大きい時間の長さの形式を表す
+// durationPattern の形式のうち、少なくとも1つの要素が0でないものに一致する
+var positiveDurationPattern = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?` + durationUnit + `)*` +
+	`([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]+\.[0-9]*[1-9][0-9]*)` + durationUnit +
+	`([0-9]+(\.[0-9]+)?` + durationUnit + `)*$`)
+
+// ErrInvalidDuration は時間の長さの形式が不正であることを表す
+var ErrInvalidDuration = errors.New("invalid duration")
+
+// ParseDuration は s を時間の長さとして解析する
+// s は durationPattern の形式でなければならず、符号 (`+`, `-`) や `.5s` のような省略した表記は受け付けない
+func ParseDuration(s string) (Duration, error) {
+	if !durationPattern.MatchString(s) {
+		return Duration{}, fmt.Errorf("%w %q: must be a non-negative number with a unit such as 30s or 1h30m", ErrInvalidDuration, s)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return Duration{}, fmt.Errorf("%w %q: must be a number with a unit such as 30s or 1h30m", ErrInvalidDuration, s)
+	}
+	return Duration{d: d, valid: true}, nil
+}
+
+// MustParseDuration は ParseDuration と同様だが、解析に失敗した場合は panic する
+func MustParseDuration(s string) Duration {
+	d, err := ParseDuration(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDuration は d から Duration を作成する
+func NewDuration(d time.Duration) Duration {
+	return Duration{d: d, valid: true}
+}
+
+// IsSet は値が設定されているかどうかを返す
+func (d Duration) IsSet() bool {
+	return d.valid || d.raw != ""
+}
+
+// IsZero は値が設定されていないかどうかを返す
+// YAMLの omitempty は IsZero によって省略するかどうかを判定する
+func (d Duration) IsZero() bool {
+	return !d.IsSet()
+}
+
+// Std は time.Duration としての値を返す
+// 値が設定されていない場合は0を返す
+func (d Duration) Std() time.Duration {
+	return d.d
+}
+
+// String は time.Duration.String と同じ形式の文字列を返す
+// 値が設定されていない場合は空文字列を返す
+func (d Duration) String() string {
+	if d.raw != "" {
+		return d.raw
+	}
+	if !d.valid {
+		return ""
+	}
+	return d.d.String()
+}
+
+// MarshalJSON は値を文字列として出力する
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON は文字列を時間の長さとして読み込む
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: must be a string", ErrInvalidDuration)
+	}
+	d.set(s)
+	return nil
+}
+
+// MarshalYAML は値を文字列として出力する
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML はスカラー値を時間の長さとして読み込む
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: %w: must be a scalar value", node.Line, ErrInvalidDuration)
+	}
+	d.set(node.Value)
+	return nil
+}
+
+// set は s を解析して d に設定する
+// 解析に失敗した場合は元の値を保持し、バリデーションで報告する
+func (d *Duration) set(s string) {
+	if s == "" {
+		*d = Duration{}
+		return
+	}
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		*d = Duration{raw: s}
+		return
+	}
+	*d = parsed
+}
+
+func (Duration) jsonSchema() map[string]any {
+	return map[string]any{
+		"type":    "string",
+		"pattern": durationPattern.String(),
+	}
+}
+
+// durationValue は Duration をバリデーション用の文字列に変換する
+// 値が設定されていない場合は空文字列になるため、`required` ルールで検出できる
+func durationValue(v reflect.Value) any {
+	d, ok := v.Interface().(Duration)
+	if !ok {
+		return nil
+	}
+	return d.String()
+}
+
+// validateDuration は `duration` ルールを実装する
+// 値が0以上の時間の長さとして解析できることを検証する
+// ParseDuration はスキーマと同じ durationPattern で検証するため、負の値はここで拒否される
+func validateDuration(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "" {
+		return true
+	}
+	_, err := ParseDuration(s)
+	return err == nil
+}
+
+// validatePositiveDuration は `positive_duration` ルールを実装する
+// 値が0より大きい時間の長さとして解析できることを検証する
+func validatePositiveDuration(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "" {
+		return true
+	}
+	d, err := ParseDuration(s)
+	return err == nil && d.Std() > 0
+}
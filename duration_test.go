@@ -0,0 +1,156 @@
+package apispec
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDuration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "30s", want: 30 * time.Second},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "1.5s", want: 1500 * time.Millisecond},
+		{input: "0", want: 0},
+		{input: "30", wantErr: true},
+		{input: "-1s", wantErr: true},
+		{input: "+1s", wantErr: true},
+		{input: "soon", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			d, err := ParseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidDuration) {
+					t.Errorf("ParseDuration(%q) error = %v, want ErrInvalidDuration", tt.input, err)
+				}
+				return
+			}
+			if d.Std() != tt.want {
+				t.Errorf("Std() = %v, want %v", d.Std(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration_Marshal(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Timeout Duration `json:"timeout" yaml:"timeout"`
+	}
+	c := config{Timeout: MustParseDuration("90m")}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"timeout":"1h30m0s"}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+	var fromJSON config
+	if err := json.Unmarshal(b, &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if fromJSON.Timeout.Std() != c.Timeout.Std() {
+		t.Errorf("json.Unmarshal() = %v, want %v", fromJSON.Timeout, c.Timeout)
+	}
+
+	y, err := yaml.Marshal(c)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	var fromYAML config
+	if err := yaml.Unmarshal(y, &fromYAML); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if fromYAML.Timeout.Std() != c.Timeout.Std() {
+		t.Errorf("yaml.Unmarshal() = %v, want %v", fromYAML.Timeout, c.Timeout)
+	}
+}
+
+func TestDuration_YAMLOmitEmpty(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Interval Duration `yaml:"interval,omitempty"`
+		Timeout  Duration `yaml:"timeout,omitempty"`
+	}
+	y, err := yaml.Marshal(config{Timeout: MustParseDuration("5s")})
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	if want := "timeout: 5s\n"; string(y) != want {
+		t.Errorf("yaml.Marshal() = %q, want %q", y, want)
+	}
+}
+
+func TestDuration_Validate(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile("testdata/processes.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := strings.Replace(string(b), "timeout: 30m", "timeout: 30 minutes", 1)
+	_, err = Load(strings.NewReader(input), FormatYAML)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Load() error = %v, want one ValidationError", err)
+	}
+	if errs[0].Path != "jobs[0].timeout" || errs[0].Rule != "duration" {
+		t.Errorf("ValidationError = %+v, want path jobs[0].timeout and rule duration", errs[0])
+	}
+	if errs[0].Position.Line != 26 {
+		t.Errorf("Position.Line = %d, want 26", errs[0].Position.Line)
+	}
+}
+
+func TestDuration_PatternMatchesValidation(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Timeout Duration `json:"timeout" validate:"duration"`
+	}
+	// スキーマの pattern とバリデーションは同じ値を受け付ける
+	for _, s := range []string{"0", "30s", "1h30m", "1.5s", "500ms", "+1s", "-1s", "-0s", ".5s", "1.s", "30"} {
+		var c config
+		if err := json.Unmarshal([]byte(`{"timeout":"`+s+`"}`), &c); err != nil {
+			t.Fatal(err)
+		}
+		valid := validateStruct(c) == nil
+		if matched := durationPattern.MatchString(s); valid != matched {
+			t.Errorf("%q: validation = %v, schema pattern = %v", s, valid, matched)
+		}
+	}
+}
+
+func TestDuration_PositivePatternMatchesValidation(t *testing.T) {
+	t.Parallel()
+	type config struct {
+		Interval Duration `json:"interval" validate:"positive_duration"`
+	}
+	// スキーマの pattern とバリデーションは同じ値を受け付ける
+	for _, s := range []string{"0", "0s", "0.0s", "0h0m", "00ms", "1ns", "30s", "0.5s", "1h0m", "0h30m", "10.0s", "-1s", "+1s", "30"} {
+		var c config
+		if err := json.Unmarshal([]byte(`{"interval":"`+s+`"}`), &c); err != nil {
+			t.Fatal(err)
+		}
+		valid := validateStruct(c) == nil
+		if matched := positiveDurationPattern.MatchString(s); valid != matched {
+			t.Errorf("%q: validation = %v, schema pattern = %v", s, valid, matched)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package apispec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// secretScheme はシークレットへの参照を表す接頭辞
+	secretScheme = "secret://"
+	// fieldScheme は設定の他のフィールドへの参照を表す接頭辞
+	fieldScheme = "field://"
+)
+
+// EnvValue は環境変数の値を表す
+//
+//   - `secret://<name>/<key>` は名前付きシークレットの値を参照する
+//   - `field://<path>` は設定の他のフィールド (例: `field://app_name`, `field://service.http[0].target_port`) を参照する
+//   - それ以外はそのままの値として扱う
+type EnvValue string
+
+func (EnvValue) jsonSchema() map[string]any {
+	return map[string]any{
+		"type": "string",
+		"anyOf": []any{
+			map[string]any{"not": map[string]any{"pattern": "^(secret|field)://"}},
+			map[string]any{"pattern": "^secret://[^/]+/[^/]+$"},
+			map[string]any{"pattern": "^field://.+$"},
+		},
+	}
+}
+
+// EnvValueKind は環境変数の値の種類を表す
+type EnvValueKind int
+
+const (
+	// EnvLiteral はそのままの値を表す
+	EnvLiteral EnvValueKind = iota
+	// EnvSecret はシークレットへの参照を表す
+	EnvSecret
+	// EnvField は設定の他のフィールドへの参照を表す
+	EnvField
+)
+
+// Kind は値の種類を返す
+func (v EnvValue) Kind() EnvValueKind {
+	switch {
+	case strings.HasPrefix(string(v), secretScheme):
+		return EnvSecret
+	case strings.HasPrefix(string(v), fieldScheme):
+		return EnvField
+	default:
+		return EnvLiteral
+	}
+}
+
+// SecretRef はシークレットへの参照の名前とキーを返す
+// シークレットへの参照でない場合、または形式が不正な場合は ok が false になる
+func (v EnvValue) SecretRef() (name, key string, ok bool) {
+	rest, found := strings.CutPrefix(string(v), secretScheme)
+	if !found {
+		return "", "", false
+	}
+	name, key, found = strings.Cut(rest, "/")
+	if !found || name == "" || key == "" || strings.Contains(key, "/") {
+		return "", "", false
+	}
+	return name, key, true
+}
+
+// FieldRef は参照先のフィールドのパスを返す
+// フィールドへの参照でない場合は ok が false になる
+func (v EnvValue) FieldRef() (path string, ok bool) {
+	path, found := strings.CutPrefix(string(v), fieldScheme)
+	if !found || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+// SecretResolver は名前付きシークレットの値を解決する
+type SecretResolver interface {
+	// ResolveSecret は name のシークレットの key の値を返す
+	ResolveSecret(ctx context.Context, name, key string) (string, error)
+}
+
+// ErrSecretNotFound はシークレットまたはそのキーが存在しないことを表す
+var ErrSecretNotFound = errors.New("secret not found")
+
+// ResolveEnv は env の参照を解決した環境変数を返す
+// シークレットへの参照は secrets で、フィールドへの参照は c の値で解決する
+// secrets が nil の場合、シークレットへの参照はエラーになる
+func (c *AppConfig) ResolveEnv(ctx context.Context, env map[string]EnvValue, secrets SecretResolver) (map[string]string, error) {
+	resolved := make(map[string]string, len(env))
+	for name, v := range env {
+		switch v.Kind() {
+		case EnvSecret:
+			secret, key, ok := v.SecretRef()
+			if !ok {
+				return nil, fmt.Errorf("env %s: malformed secret reference %q", name, v)
+			}
+			if secrets == nil {
+				return nil, fmt.Errorf("env %s: no secret resolver for %q", name, v)
+			}
+			s, err := secrets.ResolveSecret(ctx, secret, key)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %w", name, err)
+			}
+			resolved[name] = s
+		case EnvField:
+			path, _ := v.FieldRef()
+			s, err := lookupField(c, path)
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %w", name, err)
+			}
+			resolved[name] = s
+		default:
+			resolved[name] = string(v)
+		}
+	}
+	return resolved, nil
+}
+
+var envValueType = reflect.TypeFor[EnvValue]()
+
+// lookupField は c の path (例: `service.http[0].target_port`) の値を文字列として返す
+// 参照先は文字列、数値、真偽値、Quantity のいずれかでなければならない
+// 他の環境変数を経由した参照はシークレットの漏洩を防ぐため許可しない
+func lookupField(c *AppConfig, path string) (string, error) {
+	v := reflect.ValueOf(*c)
+	for _, seg := range splitFieldPath(path) {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return "", fmt.Errorf("field %q is not set", path)
+			}
+			v = v.Elem()
+		}
+		switch {
+		case seg.index >= 0:
+			if v.Kind() != reflect.Slice || seg.index >= v.Len() {
+				return "", fmt.Errorf("field %q does not exist", path)
+			}
+			v = v.Index(seg.index)
+		case v.Kind() == reflect.Struct:
+			f, ok := fieldByKey(v.Type(), seg.key)
+			if !ok {
+				return "", fmt.Errorf("field %q does not exist", path)
+			}
+			v = v.FieldByIndex(f.Field.Index)
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			v = v.MapIndex(reflect.ValueOf(seg.key).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return "", fmt.Errorf("field %q does not exist", path)
+			}
+		default:
+			return "", fmt.Errorf("field %q does not exist", path)
+		}
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", fmt.Errorf("field %q is not set", path)
+		}
+		v = v.Elem()
+	}
+	if q, ok := v.Interface().(Quantity); ok {
+		return q.String(), nil
+	}
+	if v.Type() == envValueType {
+		return "", fmt.Errorf("field %q refers to another env value", path)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	default:
+		return "", fmt.Errorf("field %q is not a scalar value", path)
+	}
+}
+
+// fieldPathSegment はフィールドのパスの1要素を表す
+// index が0以上の場合はリストの要素、それ以外は key のフィールドまたはマップのキーを表す
+type fieldPathSegment struct {
+	key   string
+	index int
+}
+
+// splitFieldPath は `service.http[0].target_port` のようなパスを要素に分割する
+func splitFieldPath(path string) []fieldPathSegment {
+	var segs []fieldPathSegment
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key != "" {
+			segs = append(segs, fieldPathSegment{key: key, index: -1})
+		}
+		for rest != "" {
+			num, after, _ := strings.Cut(rest, "]")
+			i, err := strconv.Atoi(num)
+			if err != nil || i < 0 {
+				// 不正な添字は存在しないフィールドとして扱う
+				return append(segs, fieldPathSegment{key: "[" + rest, index: -1})
+			}
+			segs = append(segs, fieldPathSegment{index: i})
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return segs
+}
+
+// fieldByKey は構造体 t から設定ファイル上のキー名が key のフィールドを探す
+func fieldByKey(t reflect.Type, key string) (structField, bool) {
+	for _, f := range structFields(t) {
+		if f.Name == key {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnvName は `envname` ルールを実装する
+// 値が環境変数名として使える文字列であることを検証する
+func validateEnvName(fl validator.FieldLevel) bool {
+	return envNamePattern.MatchString(fl.Field().String())
+}
+
+// validateEnvValue は `envvalue` ルールを実装する
+// 参照の形式が正しいことを検証する
+// 参照先のフィールドが存在するかどうかは AppConfig 全体の検証で確認する
+func validateEnvValue(fl validator.FieldLevel) bool {
+	v := EnvValue(fl.Field().String())
+	switch v.Kind() {
+	case EnvSecret:
+		_, _, ok := v.SecretRef()
+		return ok
+	case EnvField:
+		_, ok := v.FieldRef()
+		return ok
+	default:
+		return true
+	}
+}
+
+// validateNoSecret は `nosecret` ルールを実装する
+// ビルド引数はイメージに残るため、シークレットへの参照を許可しない
+func validateNoSecret(fl validator.FieldLevel) bool {
+	return EnvValue(fl.Field().String()).Kind() != EnvSecret
+}
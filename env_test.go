@@ -0,0 +1,164 @@
+package apispec
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAppConfig_Validate_Env(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		modify func(c *AppConfig)
+		want   []ValidationError
+	}{
+		{
+			name: "正しい環境変数の場合、エラーにならない",
+			modify: func(c *AppConfig) {
+				c.Service.Env = map[string]EnvValue{
+					"LOG_LEVEL":   "info",
+					"DB_PASSWORD": "secret://database/password",
+					"PORT":        "field://service.http[0].target_port",
+				}
+				c.Releases[0].Env = map[string]EnvValue{"APP_NAME": "field://app_name"}
+			},
+		},
+		{
+			name: "環境変数名が不正な場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Service.Env = map[string]EnvValue{"1PORT": "8080"}
+			},
+			want: []ValidationError{{Path: "service.env.1PORT", Rule: "envname"}},
+		},
+		{
+			name: "シークレットへの参照の形式が不正な場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Releases[0].Env = map[string]EnvValue{"DB_PASSWORD": "secret://database"}
+			},
+			want: []ValidationError{{Path: "releases[0].env.DB_PASSWORD", Rule: "envvalue"}},
+		},
+		{
+			name: "参照先のフィールドが存在しない場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Service.Env = map[string]EnvValue{"PORT": "field://service.http[1].target_port"}
+			},
+			want: []ValidationError{{Path: "service.env.PORT", Rule: "fieldref", Param: "service.http[1].target_port"}},
+		},
+		{
+			name: "他の環境変数を参照している場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Service.Env = map[string]EnvValue{
+					"DB_PASSWORD": "secret://database/password",
+					"LEAK":        "field://service.env.DB_PASSWORD",
+				}
+			},
+			want: []ValidationError{{Path: "service.env.LEAK", Rule: "fieldref", Param: "service.env.DB_PASSWORD"}},
+		},
+		{
+			name: "ビルド引数がシークレットを参照している場合、エラーになる",
+			modify: func(c *AppConfig) {
+				c.Build = BuildConfig{Dockerfile: "Dockerfile", BuildArgs: map[string]string{"NPM_TOKEN": "secret://npm/token"}}
+			},
+			want: []ValidationError{{Path: "build.build_args.NPM_TOKEN", Rule: "nosecret"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := AppConfig{
+				Version: LatestVersion,
+				AppName: "myapp",
+				Build:   BuildConfig{Image: "myapp:latest"},
+				Releases: []ReleaseConfig{{
+					Name:      "migrate",
+					Resources: ResourceConfig{CPU: MustParseQuantity("500m"), Memory: MustParseQuantity("256Mi")},
+					Action:    ReleaseActionConfig{Command: []string{"bin/migrate"}},
+				}},
+				Service: ServiceConfig{
+					Name:    "web",
+					Command: []string{"npm", "start"},
+					HTTP:    []ServiceHTTPConfig{{TargetPort: 8080}},
+				},
+			}
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), len(tt.want), errs)
+			}
+			for i, want := range tt.want {
+				got := errs[i]
+				if got.Path != want.Path || got.Rule != want.Rule || got.Param != want.Param {
+					t.Errorf("errs[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAppConfig_ResolveEnv(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFile("testdata/stages.yaml")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	secrets, err := LoadFileSecretStore("testdata/secrets.yaml")
+	if err != nil {
+		t.Fatalf("LoadFileSecretStore() error = %v", err)
+	}
+
+	t.Run("参照が解決される", func(t *testing.T) {
+		t.Parallel()
+		got, err := cfg.ResolveEnv(context.Background(), cfg.Service.Env, secrets)
+		if err != nil {
+			t.Fatalf("ResolveEnv() error = %v", err)
+		}
+		want := map[string]string{"APP_NAME": "myapp", "DATABASE_PASSWORD": "s3cr3t", "LOG_FORMAT": "json"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveEnv() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("フィールドの値は文字列に変換される", func(t *testing.T) {
+		t.Parallel()
+		env := map[string]EnvValue{
+			"MAX":    "field://service.scale.max",
+			"MEMORY": "field://service.machine_config.memory",
+		}
+		got, err := cfg.ResolveEnv(context.Background(), env, nil)
+		if err != nil {
+			t.Fatalf("ResolveEnv() error = %v", err)
+		}
+		want := map[string]string{"MAX": "10", "MEMORY": "1Gi"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveEnv() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("シークレットが存在しない場合、エラーになる", func(t *testing.T) {
+		t.Parallel()
+		env := map[string]EnvValue{"TOKEN": "secret://database/token"}
+		if _, err := cfg.ResolveEnv(context.Background(), env, secrets); !errors.Is(err, ErrSecretNotFound) {
+			t.Errorf("ResolveEnv() error = %v, want ErrSecretNotFound", err)
+		}
+	})
+
+	t.Run("SecretResolverがない場合、シークレットの参照はエラーになる", func(t *testing.T) {
+		t.Parallel()
+		if _, err := cfg.ResolveEnv(context.Background(), cfg.Service.Env, nil); err == nil {
+			t.Error("ResolveEnv() error = nil, want error")
+		}
+	})
+}
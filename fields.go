@@ -0,0 +1,67 @@
+package apispec
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// structField は設定ファイル上のキーと構造体フィールドの対応を表す
+type structField struct {
+	// Name は設定ファイル上のキー名
+	Name string
+	// OmitEmpty は omitempty が指定されているかどうか
+	OmitEmpty bool
+	// Field は対応する構造体フィールド
+	Field reflect.StructField
+}
+
+// structFields は t のフィールドを yaml タグの名前とともに宣言順に返す
+// タグが `-` のフィールドと非公開フィールドは含まない
+func structFields(t reflect.Type) []structField {
+	fields := make([]structField, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields = append(fields, structField{
+			Name:      name,
+			OmitEmpty: strings.Contains(opts, "omitempty"),
+			Field:     f,
+		})
+	}
+	return fields
+}
+
+var yamlUnmarshalerType = reflect.TypeFor[yaml.Unmarshaler]()
+
+// isOpaque は t が独自のデコード処理を持ち、内部構造を辿るべきでない型かどうかを返す
+func isOpaque(t reflect.Type) bool {
+	return t.Implements(yamlUnmarshalerType) || reflect.PointerTo(t).Implements(yamlUnmarshalerType)
+}
+
+// indirectType はポインタ型を剥がした型を返す
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// joinPath は親のパスとキーを連結する
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,258 @@
+package apispec
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatSource は設定ファイルを正規化された形式に整形する
+//
+//   - 構造体のキーは AppConfig のフィールドの宣言順に並べ、未知のキーはその後に元の順序で並べる
+//   - マップ (build_args, env など) のキーは辞書順に並べる
+//   - YAMLは2スペースでインデントし、コメントとスカラー値の表記は保持する
+//   - JSONは2スペースでインデントする
+//
+// 内容のバリデーションは行わないため、不正な値を含む設定ファイルも整形できる
+func FormatSource(data []byte, format Format) ([]byte, error) {
+	if format == FormatAuto {
+		format = detectFormat(data)
+	}
+	doc, err := parseDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return encodeNode(doc, format)
+}
+
+// Marshal は cfg を FormatSource と同じ正規化された形式の設定ファイルに変換する
+// 空の文字列と空のマップは省略する
+// format に FormatAuto を指定した場合はYAMLに変換する
+func Marshal(cfg *AppConfig, format Format) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, err
+	}
+	pruneEmpty(&root)
+	return encodeNode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&root}}, format)
+}
+
+// encodeNode は DocumentNode である doc のキーを並べ替え、format の形式で出力する
+// 最初のキーの前と最後のキーの後にあるコメントはファイル全体のコメントとみなし、並べ替えた後もファイルの先頭と末尾に残す
+func encodeNode(doc *yaml.Node, format Format) ([]byte, error) {
+	root := doc.Content[0]
+	var header, footer string
+	if n := len(root.Content); n > 0 {
+		header, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		footer, root.Content[n-2].FootComment = root.Content[n-2].FootComment, ""
+	}
+	sortNode(root, reflect.TypeFor[AppConfig]())
+	if n := len(root.Content); n > 0 {
+		first, last := root.Content[0], root.Content[n-2]
+		first.HeadComment = joinComments(header, first.HeadComment)
+		last.FootComment = joinComments(last.FootComment, footer)
+	}
+
+	if format == FormatJSON {
+		var buf bytes.Buffer
+		if err := writeJSONNode(&buf, root, ""); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// joinComments は空でないコメントを改行で連結する
+func joinComments(comments ...string) string {
+	return strings.Join(slices.DeleteFunc(comments, func(c string) bool { return c == "" }), "\n")
+}
+
+// sortNode は node のキーを t に従って並べ替える
+func sortNode(node *yaml.Node, t reflect.Type) {
+	if node.Kind == yaml.AliasNode {
+		return
+	}
+	t = indirectType(t)
+	if isOpaque(t) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := structFields(t)
+		order := make(map[string]int, len(fields))
+		types := make(map[string]reflect.Type, len(fields))
+		for i, f := range fields {
+			order[f.Name] = i
+			types[f.Name] = f.Field.Type
+		}
+		pairs := mappingPairs(node)
+		slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+			ai, aok := order[a[0].Value]
+			bi, bok := order[b[0].Value]
+			switch {
+			case aok && bok:
+				return cmp.Compare(ai, bi)
+			case aok:
+				return -1
+			case bok:
+				return 1
+			default:
+				return 0
+			}
+		})
+		setMappingPairs(node, pairs)
+		for _, p := range pairs {
+			if ft, ok := types[p[0].Value]; ok {
+				sortNode(p[1], ft)
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		pairs := mappingPairs(node)
+		slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+			return cmp.Compare(a[0].Value, b[0].Value)
+		})
+		setMappingPairs(node, pairs)
+		for _, p := range pairs {
+			sortNode(p[1], t.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			sortNode(item, t.Elem())
+		}
+	}
+}
+
+// pruneEmpty は node のマップから値が空の文字列または空のマップであるキーを取り除く
+func pruneEmpty(node *yaml.Node) {
+	for _, child := range node.Content {
+		pruneEmpty(child)
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	pairs := slices.DeleteFunc(mappingPairs(node), func(p [2]*yaml.Node) bool {
+		v := p[1]
+		return v.Kind == yaml.ScalarNode && v.ShortTag() == "!!str" && v.Value == "" ||
+			v.Kind == yaml.MappingNode && len(v.Content) == 0
+	})
+	setMappingPairs(node, pairs)
+}
+
+// mappingPairs は MappingNode のキーと値の組を返す
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	return pairs
+}
+
+func setMappingPairs(node *yaml.Node, pairs [][2]*yaml.Node) {
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p[0], p[1])
+	}
+}
+
+// writeJSONNode は node をキーの順序を保ったままJSONとして書き込む
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	inner := indent + "  "
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, p := range mappingPairs(node) {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			buf.WriteString(inner)
+			key, _ := json.Marshal(p[0].Value)
+			buf.Write(key)
+			buf.WriteString(": ")
+			if err := writeJSONNode(buf, p[1], inner); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n" + indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			buf.WriteString(inner)
+			if err := writeJSONNode(buf, item, inner); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n" + indent + "]")
+	default:
+		v, err := jsonScalar(node)
+		if err != nil {
+			return err
+		}
+		buf.Write(v)
+	}
+	return nil
+}
+
+// jsonScalar はスカラー値をJSONの値に変換する
+func jsonScalar(node *yaml.Node) ([]byte, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return []byte("null"), nil
+	case "!!bool":
+		b, err := strconv.ParseBool(node.Value)
+		if err != nil {
+			return json.Marshal(node.Value)
+		}
+		return json.Marshal(b)
+	case "!!int", "!!float":
+		if json.Valid([]byte(node.Value)) {
+			return []byte(node.Value), nil
+		}
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	default:
+		return json.Marshal(node.Value)
+	}
+}
@@ -0,0 +1,247 @@
+package apispec
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestFormatSource(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+		want   string
+	}{
+		{
+			name: "キーが宣言順に並べ替えられる",
+			input: `service:
+    command: [npm, start]
+    name: web
+app_name: myapp
+`,
+			format: FormatYAML,
+			want: `app_name: myapp
+service:
+  name: web
+  command: [npm, start]
+`,
+		},
+		{
+			name: "マップのキーは辞書順に並べられ、コメントは保持される",
+			input: `build:
+  # ビルド引数
+  build_args:
+    NODE_ENV: production
+    LOG_LEVEL: info # 一時的に変更
+  dockerfile: Dockerfile
+`,
+			format: FormatYAML,
+			want: `build:
+  dockerfile: Dockerfile
+  # ビルド引数
+  build_args:
+    LOG_LEVEL: info # 一時的に変更
+    NODE_ENV: production
+`,
+		},
+		{
+			name: "未知のキーは既知のキーの後に元の順序で並べられる",
+			input: `zzz: 1
+service: {name: web}
+aaa: 2
+app_name: myapp
+`,
+			format: FormatYAML,
+			want: `app_name: myapp
+service: {name: web}
+zzz: 1
+aaa: 2
+`,
+		},
+		{
+			name: "空行で区切られたファイル先頭と末尾のコメントは保持される",
+			input: `# Copyright header
+
+service:
+  name: web
+app_name: myapp
+# footer
+`,
+			format: FormatYAML,
+			want: `# Copyright header
+
+app_name: myapp
+service:
+  name: web
+# footer
+`,
+		},
+		{
+			name: "最初のキーに付いたコメントはファイルの先頭に残る",
+			input: `# yaml-language-server: $schema=https://example.com/appconfig.schema.json
+service:
+  name: web
+app_name: myapp
+`,
+			format: FormatYAML,
+			want: `# yaml-language-server: $schema=https://example.com/appconfig.schema.json
+app_name: myapp
+service:
+  name: web
+`,
+		},
+		{
+			name:   "JSONはJSONのまま整形される",
+			input:  `{"service": {"http": [{"force_https": true, "target_port": 8080}], "name": "web"}, "app_name": "myapp", "releases": []}`,
+			format: FormatAuto,
+			want: `{
+  "app_name": "myapp",
+  "releases": [],
+  "service": {
+    "name": "web",
+    "http": [
+      {
+        "target_port": 8080,
+        "force_https": true
+      }
+    ]
+  }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := FormatSource([]byte(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("FormatSource() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FormatSource() = \n%s\nwant\n%s", got, tt.want)
+			}
+			again, err := FormatSource(got, tt.format)
+			if err != nil {
+				t.Fatalf("FormatSource() error = %v", err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("FormatSource() is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatSource_Loadable(t *testing.T) {
+	t.Parallel()
+	for _, file := range []string{"testdata/valid.yaml", "testdata/valid.json", "testdata/stages.yaml", "testdata/processes.yaml"} {
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatted, err := FormatSource(data, FormatFromPath(file))
+			if err != nil {
+				t.Fatalf("FormatSource() error = %v", err)
+			}
+			if _, err := Load(bytes.NewReader(formatted), FormatFromPath(file), WithStrict()); err != nil {
+				t.Errorf("Load() of the formatted document error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+	cfg := &AppConfig{
+		Version:  LatestVersion,
+		AppName:  "myapp",
+		Build:    BuildConfig{Dockerfile: "Dockerfile"},
+		Releases: []ReleaseConfig{},
+		Service: ServiceConfig{
+			Name:    "web",
+			Command: []string{"npm", "start"},
+			HTTP:    []ServiceHTTPConfig{{TargetPort: 8080}},
+			Env:     map[string]EnvValue{"PORT": "8080", "LOG_LEVEL": "info"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "空の値を省略したYAMLに変換する",
+			format: FormatYAML,
+			want: `version: v1
+app_name: myapp
+build:
+  dockerfile: Dockerfile
+releases: []
+service:
+  name: web
+  command:
+    - npm
+    - start
+  http:
+    - target_port: 8080
+  env:
+    LOG_LEVEL: info
+    PORT: "8080"
+`,
+		},
+		{
+			name:   "JSONに変換する",
+			format: FormatJSON,
+			want: `{
+  "version": "v1",
+  "app_name": "myapp",
+  "build": {
+    "dockerfile": "Dockerfile"
+  },
+  "releases": [],
+  "service": {
+    "name": "web",
+    "command": [
+      "npm",
+      "start"
+    ],
+    "http": [
+      {
+        "target_port": 8080
+      }
+    ],
+    "env": {
+      "LOG_LEVEL": "info",
+      "PORT": "8080"
+    }
+  }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Marshal(cfg, tt.format)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = \n%s\nwant\n%s", got, tt.want)
+			}
+			loaded, err := Load(bytes.NewReader(got), tt.format, WithStrict())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if loaded.Service.Env["PORT"] != "8080" {
+				t.Errorf("Load() service.env.PORT = %q, want 8080", loaded.Service.Env["PORT"])
+			}
+		})
+	}
+}
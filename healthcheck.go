@@ -0,0 +1,193 @@
+package apispec
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ヘルスチェックのタイミングのデフォルト値
+const (
+	// DefaultHealthcheckInterval はヘルスチェックの間隔のデフォルト値
+	DefaultHealthcheckInterval = 10 * time.Second
+	// DefaultHealthcheckTimeout は1回のヘルスチェックの制限時間のデフォルト値
+	DefaultHealthcheckTimeout = time.Second
+	// DefaultHealthcheckSuccessThreshold は正常とみなすまでに必要な連続した成功の回数のデフォルト値
+	DefaultHealthcheckSuccessThreshold = 1
+	// DefaultHealthcheckFailureThreshold は異常とみなすまでに必要な連続した失敗の回数のデフォルト値
+	DefaultHealthcheckFailureThreshold = 3
+)
+
+// ProbeRole はヘルスチェックの役割を表す
+type ProbeRole string
+
+const (
+	// ProbeReadiness はサービスがリクエストを受け付けられるかどうかを判定する
+	ProbeReadiness ProbeRole = "readiness"
+	// ProbeLiveness はサービスが動作し続けているかどうかを判定する
+	ProbeLiveness ProbeRole = "liveness"
+	// ProbeStartup はサービスの起動が完了したかどうかを判定する
+	ProbeStartup ProbeRole = "startup"
+)
+
+// ProbeRoles はすべての役割を評価される順に並べたもの
+var ProbeRoles = []ProbeRole{ProbeStartup, ProbeReadiness, ProbeLiveness}
+
+// Probe は role のヘルスチェック設定を返す
+// readiness は Probes.Readiness が省略されている場合に Healthcheck を返す
+// 設定されていない場合は nil を返す
+func (s *ServiceConfig) Probe(role ProbeRole) *HealthcheckConfig {
+	var p ProbesConfig
+	if s.Probes != nil {
+		p = *s.Probes
+	}
+	switch role {
+	case ProbeReadiness:
+		if p.Readiness != nil {
+			return p.Readiness
+		}
+		return s.Healthcheck
+	case ProbeLiveness:
+		return p.Liveness
+	case ProbeStartup:
+		return p.Startup
+	default:
+		return nil
+	}
+}
+
+// HealthcheckPort は h が対象とするポートを返す
+// ポートが省略されている場合は最初の HTTP.TargetPort を返す
+// プロセスヘルスチェックの場合、または対象のポートがない場合は0を返す
+func (s *ServiceConfig) HealthcheckPort(h *HealthcheckConfig) int {
+	port, network := h.port()
+	if !network {
+		return 0
+	}
+	if port == 0 && len(s.HTTP) > 0 {
+		return s.HTTP[0].TargetPort
+	}
+	return port
+}
+
+// HealthcheckKind はヘルスチェックの種類を表す
+type HealthcheckKind string
+
+const (
+	// HealthcheckHTTP はHTTPヘルスチェックを表す
+	HealthcheckHTTP HealthcheckKind = "http"
+	// HealthcheckProcess はプロセスヘルスチェックを表す
+	HealthcheckProcess HealthcheckKind = "process"
+	// HealthcheckTCP はTCPヘルスチェックを表す
+	HealthcheckTCP HealthcheckKind = "tcp"
+	// HealthcheckGRPC はgRPCヘルスチェックを表す
+	HealthcheckGRPC HealthcheckKind = "grpc"
+)
+
+// Kind はヘルスチェックの種類を返す
+// 種類が設定されていない場合は空文字列を返す
+func (h *HealthcheckConfig) Kind() HealthcheckKind {
+	switch {
+	case h.HTTP != nil:
+		return HealthcheckHTTP
+	case h.Process != nil:
+		return HealthcheckProcess
+	case h.TCP != nil:
+		return HealthcheckTCP
+	case h.GRPC != nil:
+		return HealthcheckGRPC
+	default:
+		return ""
+	}
+}
+
+// port は明示的に指定されたポートと、ヘルスチェックがポートを対象とするかどうかを返す
+func (h *HealthcheckConfig) port() (port int, network bool) {
+	switch {
+	case h.HTTP != nil:
+		return h.HTTP.Port, true
+	case h.TCP != nil:
+		return h.TCP.Port, true
+	case h.GRPC != nil:
+		return h.GRPC.Port, true
+	default:
+		return 0, false
+	}
+}
+
+// HealthcheckTimings はデフォルト値を適用したヘルスチェックのタイミングを表す
+type HealthcheckTimings struct {
+	InitialDelay     time.Duration
+	Interval         time.Duration
+	Timeout          time.Duration
+	SuccessThreshold int
+	FailureThreshold int
+}
+
+// Timings は省略されたフィールドにデフォルト値を適用したタイミングを返す
+func (h *HealthcheckConfig) Timings() HealthcheckTimings {
+	t := HealthcheckTimings{
+		InitialDelay:     h.InitialDelay.Std(),
+		Interval:         h.Interval.Std(),
+		Timeout:          h.Timeout.Std(),
+		SuccessThreshold: h.SuccessThreshold,
+		FailureThreshold: h.FailureThreshold,
+	}
+	if !h.Interval.IsSet() {
+		t.Interval = DefaultHealthcheckInterval
+	}
+	if !h.Timeout.IsSet() {
+		t.Timeout = DefaultHealthcheckTimeout
+	}
+	if t.SuccessThreshold == 0 {
+		t.SuccessThreshold = DefaultHealthcheckSuccessThreshold
+	}
+	if t.FailureThreshold == 0 {
+		t.FailureThreshold = DefaultHealthcheckFailureThreshold
+	}
+	return t
+}
+
+// validateServiceProbes はサービスのヘルスチェックがサービスの設定と整合していることを検証する
+//
+//   - Healthcheck と Probes.Readiness は同時に指定できない
+//   - ヘルスチェックのポートは service.http の target_port のいずれかでなければならない
+//   - ポートを省略する場合は service.http が1つ以上定義されていなければならない
+func validateServiceProbes(sl validator.StructLevel, s ServiceConfig) {
+	if s.Healthcheck != nil && s.Probes != nil && s.Probes.Readiness != nil {
+		sl.ReportError(s.Healthcheck, "service.healthcheck", "", "excluded_with", "service.probes.readiness")
+	}
+
+	ports := make([]string, len(s.HTTP))
+	for i, h := range s.HTTP {
+		ports[i] = strconv.Itoa(h.TargetPort)
+	}
+	probes := map[string]*HealthcheckConfig{"service.healthcheck": s.Healthcheck}
+	if s.Probes != nil {
+		probes["service.probes.readiness"] = s.Probes.Readiness
+		probes["service.probes.liveness"] = s.Probes.Liveness
+		probes["service.probes.startup"] = s.Probes.Startup
+	}
+	for _, path := range slices.Sorted(maps.Keys(probes)) {
+		h := probes[path]
+		if h == nil {
+			continue
+		}
+		port, network := h.port()
+		if !network {
+			continue
+		}
+		path = fmt.Sprintf("%s.%s.port", path, h.Kind())
+		switch {
+		case port == 0 && len(ports) == 0:
+			sl.ReportError(port, path, "", "required", "")
+		case port != 0 && !slices.Contains(ports, strconv.Itoa(port)):
+			sl.ReportError(port, path, "", "targetport", strings.Join(ports, " "))
+		}
+	}
+}
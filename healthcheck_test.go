@@ -0,0 +1,139 @@
+package apispec
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestServiceConfig_Probe(t *testing.T) {
+	t.Parallel()
+	healthcheck := &HealthcheckConfig{HTTP: &HealthcheckHTTPConfig{Path: "/healthz"}}
+	liveness := &HealthcheckConfig{TCP: &HealthcheckTCPConfig{}}
+	readiness := &HealthcheckConfig{HTTP: &HealthcheckHTTPConfig{Path: "/ready"}}
+
+	s := ServiceConfig{Healthcheck: healthcheck, Probes: &ProbesConfig{Liveness: liveness}}
+	if got := s.Probe(ProbeReadiness); got != healthcheck {
+		t.Errorf("Probe(readiness) = %+v, want Healthcheck", got)
+	}
+	if got := s.Probe(ProbeLiveness); got != liveness {
+		t.Errorf("Probe(liveness) = %+v, want Probes.Liveness", got)
+	}
+	if got := s.Probe(ProbeStartup); got != nil {
+		t.Errorf("Probe(startup) = %+v, want nil", got)
+	}
+
+	s = ServiceConfig{Probes: &ProbesConfig{Readiness: readiness}}
+	if got := s.Probe(ProbeReadiness); got != readiness {
+		t.Errorf("Probe(readiness) = %+v, want Probes.Readiness", got)
+	}
+}
+
+func TestHealthcheckConfig_Timings(t *testing.T) {
+	t.Parallel()
+	h := HealthcheckConfig{TCP: &HealthcheckTCPConfig{}, Interval: MustParseDuration("30s"), FailureThreshold: 5}
+	want := HealthcheckTimings{
+		Interval:         30 * time.Second,
+		Timeout:          DefaultHealthcheckTimeout,
+		SuccessThreshold: DefaultHealthcheckSuccessThreshold,
+		FailureThreshold: 5,
+	}
+	if got := h.Timings(); got != want {
+		t.Errorf("Timings() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAppConfig_Validate_Probes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		modify func(s *ServiceConfig)
+		want   []ValidationError
+	}{
+		{
+			name: "宣言されたポートを参照している場合、エラーにならない",
+			modify: func(s *ServiceConfig) {
+				s.Healthcheck = &HealthcheckConfig{HTTP: &HealthcheckHTTPConfig{Path: "/healthz", Port: 9090}}
+				s.Probes = &ProbesConfig{
+					Liveness: &HealthcheckConfig{TCP: &HealthcheckTCPConfig{}},
+					Startup:  &HealthcheckConfig{Process: &HealthcheckProcessConfig{Command: []string{"test", "-f", "/tmp/ready"}}},
+				}
+			},
+		},
+		{
+			name: "宣言されていないポートを参照している場合、エラーになる",
+			modify: func(s *ServiceConfig) {
+				s.Probes = &ProbesConfig{Liveness: &HealthcheckConfig{GRPC: &HealthcheckGRPCConfig{Port: 50051}}}
+			},
+			want: []ValidationError{{Path: "service.probes.liveness.grpc.port", Rule: "targetport", Param: "8080 9090"}},
+		},
+		{
+			name: "ポートを省略しHTTPも宣言されていない場合、エラーになる",
+			modify: func(s *ServiceConfig) {
+				s.HTTP = nil
+				s.Healthcheck = &HealthcheckConfig{TCP: &HealthcheckTCPConfig{}}
+			},
+			want: []ValidationError{{Path: "service.healthcheck.tcp.port", Rule: "required"}},
+		},
+		{
+			name: "間隔と制限時間が0の場合、エラーになる",
+			modify: func(s *ServiceConfig) {
+				s.Probes = &ProbesConfig{Liveness: &HealthcheckConfig{
+					TCP:      &HealthcheckTCPConfig{},
+					Interval: MustParseDuration("0s"),
+					Timeout:  MustParseDuration("0"),
+				}}
+			},
+			want: []ValidationError{
+				{Path: "service.probes.liveness.interval", Rule: "positive_duration"},
+				{Path: "service.probes.liveness.timeout", Rule: "positive_duration"},
+			},
+		},
+		{
+			name: "HealthcheckとProbes.Readinessがともに設定されている場合、エラーになる",
+			modify: func(s *ServiceConfig) {
+				s.Healthcheck = &HealthcheckConfig{TCP: &HealthcheckTCPConfig{}}
+				s.Probes = &ProbesConfig{Readiness: &HealthcheckConfig{TCP: &HealthcheckTCPConfig{}}}
+			},
+			want: []ValidationError{{Path: "service.healthcheck", Rule: "excluded_with", Param: "service.probes.readiness"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := AppConfig{
+				Version:  LatestVersion,
+				AppName:  "myapp",
+				Build:    BuildConfig{Image: "myapp:latest"},
+				Releases: []ReleaseConfig{},
+				Service: ServiceConfig{
+					Name:    "web",
+					Command: []string{"npm", "start"},
+					HTTP:    []ServiceHTTPConfig{{TargetPort: 8080}, {TargetPort: 9090}},
+				},
+			}
+			tt.modify(&cfg.Service)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), len(tt.want), errs)
+			}
+			for i, want := range tt.want {
+				got := errs[i]
+				if got.Path != want.Path || got.Rule != want.Rule || got.Param != want.Param {
+					t.Errorf("errs[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,669 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	apispec "github.com/tacokumo/appconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoServices は compose のファイルにサービスが定義されていないことを表す
+var ErrNoServices = errors.New("compose file defines no services")
+
+// composeConditionCompleted はリリースへの依存を表す depends_on の condition
+const composeConditionCompleted = "service_completed_successfully"
+
+// variablePattern は変数の展開のみからなる値 (例: `${DATABASE_URL}`, `$DATABASE_URL`) を表す
+var variablePattern = regexp.MustCompile(`^\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))$`)
+
+// silentKeys は AppConfig に影響しないため警告しないサービスのキーを表す
+var silentKeys = []string{"container_name", "restart", "tty", "stdin_open", "init"}
+
+// Compose は docker-compose のファイルから AppConfig を作成する
+//
+//   - 他のサービスから `condition: service_completed_successfully` で依存されるサービスはリリースに変換し、依存関係の順に並べる
+//     リリースの名前からは render/compose が付ける `release-` 接頭辞を取り除く
+//   - `web` という名前のサービス、ポートを公開する最初のサービス、最初のサービスの順にサービスとして選び、残りはワーカーに変換する
+//     サービスと異なるイメージを使うサービスはデータベースなどの外部のサービスとみなし、変換しない
+//   - profiles を持つサービスは手動で実行するものとみなし、変換しない
+//   - サービスのビルド設定またはイメージを build に変換する
+//   - 変数の展開のみからなる環境変数の値はシークレットへの参照にする
+//   - curl, wget, nc, grpc_health_probe によるヘルスチェックはそれぞれ HTTP, TCP, gRPC のヘルスチェックに、
+//     それ以外はプロセスヘルスチェックに変換する
+func Compose(data []byte, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	res := &Result{}
+	for _, key := range sortedKeys(doc) {
+		if key != "name" && key != "services" && key != "version" && !strings.HasPrefix(key, "x-") {
+			res.warnf("", key, "top-level %s is not supported", key)
+		}
+	}
+	services := map[string]map[string]any{}
+	raw, _ := doc["services"].(map[string]any)
+	for _, name := range sortedKeys(raw) {
+		svc, ok := raw[name].(map[string]any)
+		if !ok {
+			res.warnf("", "services."+name, "service is not a mapping")
+			continue
+		}
+		if _, ok := svc["profiles"]; ok {
+			res.warnf("", "services."+name+".profiles", "services with profiles are not imported; add them to jobs if they run on a schedule")
+			continue
+		}
+		services[name] = svc
+	}
+	if len(services) == 0 {
+		return nil, ErrNoServices
+	}
+
+	name, _ := doc["name"].(string)
+	c := &composeImporter{res: res, opts: opts, cfg: newConfig(opts.appName(name), opts), services: services}
+	c.convert()
+	res.Config = c.cfg
+	return res, nil
+}
+
+type composeImporter struct {
+	res      *Result
+	opts     Options
+	cfg      *apispec.AppConfig
+	services map[string]map[string]any
+	// build は最初に変換したサービスのビルド設定
+	build *apispec.BuildConfig
+}
+
+func (c *composeImporter) convert() {
+	releases := c.releases()
+	main := c.mainService(releases)
+
+	svc := c.services[main]
+	c.convertBuild(main, svc)
+	c.cfg.Service = apispec.ServiceConfig{Name: main, Command: c.command(main, svc)}
+	for _, port := range c.ports(main, svc) {
+		c.cfg.Service.HTTP = append(c.cfg.Service.HTTP, apispec.ServiceHTTPConfig{TargetPort: port})
+	}
+	c.cfg.Service.Env = c.environment(main, svc)
+	c.cfg.Service.Healthcheck = c.healthcheck(main, svc)
+	if res, ok := c.resources(main, svc); ok {
+		c.cfg.Service.MachineConfig = &apispec.MachineConfig{CPU: res.CPU, Memory: res.Memory}
+	}
+	c.checkKeys(main, svc, "ports", "healthcheck")
+
+	for _, name := range releases {
+		svc := c.services[name]
+		c.convertBuild(name, svc)
+		res, ok := c.resources(name, svc)
+		if !ok {
+			res = c.opts.Resources
+		}
+		c.cfg.Releases = append(c.cfg.Releases, apispec.ReleaseConfig{
+			Name:      strings.TrimPrefix(name, "release-"),
+			Resources: res,
+			Action:    apispec.ReleaseActionConfig{Command: c.command(name, svc)},
+			Env:       c.environment(name, svc),
+		})
+		c.checkKeys(name, svc)
+	}
+
+	for _, name := range sortedKeys(c.services) {
+		if name == main || slices.Contains(releases, name) {
+			continue
+		}
+		svc := c.services[name]
+		if !c.sameBuild(name, svc) {
+			c.res.warnf("", "services."+name, "uses a different image from %s; backing services are not imported and must be provisioned separately", main)
+			continue
+		}
+		w := apispec.WorkerConfig{
+			Name:    name,
+			Command: c.command(name, svc),
+			Env:     c.environment(name, svc),
+		}
+		if res, ok := c.resources(name, svc); ok {
+			w.MachineConfig = &apispec.MachineConfig{CPU: res.CPU, Memory: res.Memory}
+		}
+		c.cfg.Workers = append(c.cfg.Workers, w)
+		c.checkKeys(name, svc)
+	}
+}
+
+// dependsOn は svc の depends_on をサービスの名前から condition への対応に変換する
+func dependsOn(svc map[string]any) map[string]string {
+	deps := map[string]string{}
+	switch v := svc["depends_on"].(type) {
+	case []any:
+		for _, d := range v {
+			deps[fmt.Sprint(d)] = "service_started"
+		}
+	case map[string]any:
+		for name, d := range v {
+			cond, _ := asMap(d)["condition"].(string)
+			if cond == "" {
+				cond = "service_started"
+			}
+			deps[name] = cond
+		}
+	}
+	return deps
+}
+
+// releases はリリースとみなすサービスの名前を依存関係の順に返す
+// 依存関係のないサービスは名前の順に並べる
+func (c *composeImporter) releases() []string {
+	isRelease := map[string]bool{}
+	for _, svc := range c.services {
+		for dep, cond := range dependsOn(svc) {
+			if cond == composeConditionCompleted && c.services[dep] != nil {
+				isRelease[dep] = true
+			}
+		}
+	}
+	var ordered []string
+	for len(ordered) < len(isRelease) {
+		progressed := false
+		for _, name := range sortedKeys(isRelease) {
+			if slices.Contains(ordered, name) {
+				continue
+			}
+			ready := true
+			for dep := range dependsOn(c.services[name]) {
+				if isRelease[dep] && !slices.Contains(ordered, dep) {
+					ready = false
+				}
+			}
+			if ready {
+				ordered = append(ordered, name)
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			// 循環している場合は残りを名前の順に並べる
+			for _, name := range sortedKeys(isRelease) {
+				if !slices.Contains(ordered, name) {
+					ordered = append(ordered, name)
+				}
+			}
+			c.res.warnf("", "services", "releases depend on each other in a cycle; they are ordered by name")
+		}
+	}
+	return ordered
+}
+
+// mainService はサービスとして変換するサービスの名前を返す
+func (c *composeImporter) mainService(releases []string) string {
+	var candidates []string
+	for _, name := range sortedKeys(c.services) {
+		if !slices.Contains(releases, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = sortedKeys(c.services)
+	}
+	if slices.Contains(candidates, "web") {
+		return "web"
+	}
+	for _, name := range candidates {
+		if _, ok := c.services[name]["ports"]; ok {
+			return name
+		}
+	}
+	return candidates[0]
+}
+
+// convertBuild は svc のビルド設定またはイメージを build に変換する
+// 2つ目以降のサービスのビルド設定が異なる場合は警告する
+func (c *composeImporter) convertBuild(name string, svc map[string]any) {
+	if c.opts.Image != "" {
+		return
+	}
+	b, ok := c.parseBuild(name, svc)
+	if !ok {
+		return
+	}
+	if c.build == nil {
+		c.build = &b
+		c.cfg.Build = b
+		return
+	}
+	if !reflect.DeepEqual(*c.build, b) {
+		c.res.warnf("", "services."+name, "uses a different build or image from the other services; the first one is used for all processes")
+	}
+}
+
+// sameBuild は svc のビルド設定またはイメージが最初に変換したサービスと同じかどうかを返す
+func (c *composeImporter) sameBuild(name string, svc map[string]any) bool {
+	if c.opts.Image != "" || c.build == nil {
+		return true
+	}
+	b, ok := c.parseBuild(name, svc)
+	return !ok || reflect.DeepEqual(*c.build, b)
+}
+
+// parseBuild は svc のビルド設定またはイメージを返す
+// どちらもない場合は false を返す
+func (c *composeImporter) parseBuild(name string, svc map[string]any) (apispec.BuildConfig, bool) {
+	var b apispec.BuildConfig
+	p := "services." + name
+	switch v := svc["build"].(type) {
+	case nil:
+		image, _ := svc["image"].(string)
+		if image == "" {
+			c.res.warnf("", p, "neither build nor image is set")
+			return b, false
+		}
+		b.Image = image
+	case string:
+		b = dockerBuild(v, "")
+	case map[string]any:
+		context, _ := v["context"].(string)
+		dockerfile, _ := v["dockerfile"].(string)
+		b = dockerBuild(context, dockerfile)
+		b.BuildArgs = c.stringMap(p+".build.args", v["args"])
+		for _, key := range sortedKeys(v) {
+			if key != "context" && key != "dockerfile" && key != "args" {
+				c.res.warnf("", p+".build."+key, "build.%s is not supported", key)
+			}
+		}
+	default:
+		c.res.warnf("", p+".build", "malformed build")
+		return b, false
+	}
+	return b, true
+}
+
+// dockerBuild はコンテキストとコンテキストからの相対パスの Dockerfile を BuildConfig に変換する
+// コンテキストが Dockerfile のあるディレクトリの場合は docker_context を省略する
+func dockerBuild(context, dockerfile string) apispec.BuildConfig {
+	if context == "" {
+		context = "."
+	}
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	b := apispec.BuildConfig{Dockerfile: path.Join(context, dockerfile)}
+	if path.Clean(context) != path.Dir(b.Dockerfile) {
+		b.DockerContext = context
+	}
+	return b
+}
+
+// command は svc の command を変換する
+func (c *composeImporter) command(name string, svc map[string]any) []string {
+	p := "services." + name
+	if _, ok := svc["entrypoint"]; ok {
+		c.res.warnf("", p+".entrypoint", "entrypoint is not supported; include it in the command")
+	}
+	switch v := svc["command"].(type) {
+	case string:
+		return splitCommand(v)
+	case []any:
+		command := make([]string, len(v))
+		for i, arg := range v {
+			command[i] = fmt.Sprint(arg)
+		}
+		return command
+	default:
+		c.res.warnf("", p, "no command is set; the default command of the image cannot be imported")
+		return nil
+	}
+}
+
+// environment は svc の environment を変換する
+func (c *composeImporter) environment(name string, svc map[string]any) map[string]apispec.EnvValue {
+	p := "services." + name
+	if _, ok := svc["env_file"]; ok {
+		c.res.warnf("", p+".env_file", "env_file is not supported; add the variables to env")
+	}
+	values := map[string]*string{}
+	switch v := svc["environment"].(type) {
+	case nil:
+		return nil
+	case []any:
+		for _, item := range v {
+			k, val, ok := strings.Cut(fmt.Sprint(item), "=")
+			if ok {
+				values[k] = &val
+			} else {
+				values[k] = nil
+			}
+		}
+	case map[string]any:
+		for k, val := range v {
+			if val == nil {
+				values[k] = nil
+				continue
+			}
+			s := fmt.Sprint(val)
+			values[k] = &s
+		}
+	default:
+		c.res.warnf("", p+".environment", "malformed environment")
+		return nil
+	}
+
+	env := make(map[string]apispec.EnvValue, len(values))
+	for _, k := range sortedKeys(values) {
+		val := values[k]
+		envPath := p + ".environment." + k
+		if val == nil {
+			ref := secretRef(c.cfg.AppName, k)
+			c.res.warnf("", envPath, "the value is taken from the host; mapped to %s", ref)
+			env[k] = ref
+			continue
+		}
+		if m := variablePattern.FindStringSubmatch(*val); m != nil {
+			ref := secretRef(c.cfg.AppName, m[1]+m[2])
+			c.res.warnf("", envPath, "the value is interpolated from %s; mapped to %s", *val, ref)
+			env[k] = ref
+			continue
+		}
+		if strings.Contains(strings.ReplaceAll(*val, "$$", ""), "$") {
+			c.res.warnf("", envPath, "variable interpolation is not supported; the value is kept as is")
+		}
+		env[k] = apispec.EnvValue(strings.ReplaceAll(*val, "$$", "$"))
+	}
+	// PORT が最初の target_port と同じ場合は runner や render と同様に自動で渡されるため省略する
+	if http := c.cfg.Service.HTTP; len(http) > 0 && env["PORT"] == apispec.EnvValue(strconv.Itoa(http[0].TargetPort)) {
+		delete(env, "PORT")
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// ports は svc の ports からコンテナのポートを返す
+func (c *composeImporter) ports(name string, svc map[string]any) []int {
+	p := "services." + name + ".ports"
+	items, _ := svc["ports"].([]any)
+	var ports []int
+	for _, item := range items {
+		var port int
+		var err error
+		switch v := item.(type) {
+		case int:
+			port = v
+		case string:
+			spec, _, _ := strings.Cut(v, "/")
+			spec = spec[strings.LastIndex(spec, ":")+1:]
+			port, err = strconv.Atoi(spec)
+		case map[string]any:
+			port, _ = v["target"].(int)
+		}
+		if err != nil || port <= 0 {
+			c.res.warnf("", p, "port %v is not supported", item)
+			continue
+		}
+		if !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// healthcheck は svc の healthcheck を変換する
+func (c *composeImporter) healthcheck(name string, svc map[string]any) *apispec.HealthcheckConfig {
+	p := "services." + name + ".healthcheck"
+	hc := asMap(svc["healthcheck"])
+	if hc == nil || hc["disable"] == true {
+		return nil
+	}
+	var args []string
+	var shell string
+	switch v := hc["test"].(type) {
+	case string:
+		shell = v
+	case []any:
+		for _, a := range v {
+			args = append(args, fmt.Sprint(a))
+		}
+		if len(args) == 0 || args[0] == "NONE" {
+			return nil
+		}
+		if args[0] == "CMD-SHELL" {
+			shell = strings.Join(args[1:], " ")
+			args = nil
+		} else if args[0] == "CMD" {
+			args = args[1:]
+		}
+	default:
+		c.res.warnf("", p, "healthcheck without test is not supported")
+		return nil
+	}
+	h := &apispec.HealthcheckConfig{}
+	fields := args
+	if shell != "" {
+		fields = strings.Fields(shell)
+	}
+	if !c.networkCheck(h, fields) {
+		if shell != "" {
+			args = []string{"sh", "-c", shell}
+		}
+		h.Process = &apispec.HealthcheckProcessConfig{Command: args}
+	}
+
+	for _, f := range []struct {
+		key string
+		d   *apispec.Duration
+	}{{"interval", &h.Interval}, {"timeout", &h.Timeout}, {"start_period", &h.InitialDelay}} {
+		s, ok := hc[f.key].(string)
+		if !ok {
+			continue
+		}
+		d, err := apispec.ParseDuration(s)
+		if err != nil {
+			c.res.warnf("", p+"."+f.key, "invalid duration %q", s)
+			continue
+		}
+		*f.d = d
+	}
+	if retries, ok := hc["retries"].(int); ok && retries > 0 {
+		h.FailureThreshold = retries
+	}
+	return h
+}
+
+// networkCheck は curl, wget, nc, grpc_health_probe のコマンドを h のネットワークのヘルスチェックに変換する
+// 変換できない場合は false を返す
+func (c *composeImporter) networkCheck(h *apispec.HealthcheckConfig, fields []string) bool {
+	if len(fields) == 0 {
+		return false
+	}
+	defaultPort := 0
+	if len(c.cfg.Service.HTTP) > 0 {
+		defaultPort = c.cfg.Service.HTTP[0].TargetPort
+	}
+	// port は最初の target_port と同じポートを省略する
+	port := func(p int) int {
+		if p == defaultPort {
+			return 0
+		}
+		return p
+	}
+	switch path.Base(fields[0]) {
+	case "curl", "wget":
+		var headers map[string]string
+		for i, f := range fields {
+			if (f == "-H" || f == "--header") && i+1 < len(fields) {
+				k, v, _ := strings.Cut(fields[i+1], ":")
+				if headers == nil {
+					headers = map[string]string{}
+				}
+				headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+			u, err := url.Parse(strings.Trim(f, `'"`))
+			if err != nil || u.Scheme != "http" && u.Scheme != "https" || !isLocalHost(u.Hostname()) {
+				continue
+			}
+			p := 80
+			if u.Scheme == "https" {
+				p = 443
+			}
+			if u.Port() != "" {
+				p, _ = strconv.Atoi(u.Port())
+			}
+			h.HTTP = &apispec.HealthcheckHTTPConfig{Path: u.RequestURI(), Port: port(p)}
+		}
+		if h.HTTP != nil {
+			h.HTTP.Headers = headers
+		}
+		return h.HTTP != nil
+	case "nc":
+		if len(fields) >= 4 && fields[1] == "-z" && isLocalHost(fields[2]) {
+			if p, err := strconv.Atoi(fields[3]); err == nil {
+				h.TCP = &apispec.HealthcheckTCPConfig{Port: port(p)}
+				return true
+			}
+		}
+	case "grpc_health_probe":
+		g := &apispec.HealthcheckGRPCConfig{}
+		ok := false
+		for _, f := range fields[1:] {
+			if addr, found := strings.CutPrefix(f, "-addr="); found {
+				host, p, _ := strings.Cut(addr, ":")
+				n, err := strconv.Atoi(p)
+				if err != nil || !isLocalHost(host) {
+					return false
+				}
+				g.Port, ok = port(n), true
+			}
+			if svc, found := strings.CutPrefix(f, "-service="); found {
+				g.Service = svc
+			}
+		}
+		if ok {
+			h.GRPC = g
+		}
+		return ok
+	}
+	return false
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "0.0.0.0" || host == "::1" || host == ""
+}
+
+// resources は svc の CPU とメモリの上限を返す
+// 一方のみが指定されている場合、もう一方は Options.Resources の値を使用する
+func (c *composeImporter) resources(name string, svc map[string]any) (apispec.ResourceConfig, bool) {
+	p := "services." + name
+	deploy := asMap(svc["deploy"])
+	for _, key := range sortedKeys(deploy) {
+		if key != "resources" {
+			c.res.warnf("", p+".deploy."+key, "deploy.%s is not supported", key)
+		}
+	}
+	resources := asMap(deploy["resources"])
+	for _, key := range sortedKeys(resources) {
+		if key != "limits" {
+			c.res.warnf("", p+".deploy.resources."+key, "deploy.resources.%s is not supported; limits are used", key)
+		}
+	}
+	limits := asMap(resources["limits"])
+	cpus, memory := limits["cpus"], limits["memory"]
+	if v, ok := svc["cpus"]; ok {
+		cpus = v
+	}
+	if v, ok := svc["mem_limit"]; ok {
+		memory = v
+	}
+	if cpus == nil && memory == nil {
+		return apispec.ResourceConfig{}, false
+	}
+	res := c.opts.Resources
+	if cpus != nil {
+		f, err := strconv.ParseFloat(fmt.Sprint(cpus), 64)
+		if err != nil || f <= 0 {
+			c.res.warnf("", p, "invalid cpus %v", cpus)
+		} else {
+			res.CPU = apispec.NewMilliQuantity(int64(math.Round(f * 1000)))
+		}
+	}
+	if memory != nil {
+		q, err := parseMemory(fmt.Sprint(memory))
+		if err != nil {
+			c.res.warnf("", p, "invalid memory limit %v", memory)
+		} else {
+			res.Memory = q
+		}
+	}
+	return res, true
+}
+
+// memoryPattern は compose のバイト数の表記 (例: `512m`, `1gb`) を表す
+var memoryPattern = regexp.MustCompile(`^(?i)(\d+)\s*([bkmg]?)b?$`)
+
+// parseMemory は compose のバイト数の表記を2進数の単位系のリソース量に変換する
+func parseMemory(s string) (apispec.Quantity, error) {
+	m := memoryPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return apispec.Quantity{}, fmt.Errorf("invalid memory %q", s)
+	}
+	suffix := map[string]string{"": "", "b": "", "k": "Ki", "m": "Mi", "g": "Gi"}[strings.ToLower(m[2])]
+	q, err := apispec.ParseQuantity(m[1] + suffix)
+	if err != nil {
+		return apispec.Quantity{}, err
+	}
+	// ParseQuantity で範囲を検証済みのため、バイト数はミリ単位で表せる
+	return apispec.NewBinaryQuantity(q.Value()), nil
+}
+
+// stringMap は map または `KEY=value` のリストを文字列のマップに変換する
+func (c *composeImporter) stringMap(p string, v any) map[string]string {
+	m := map[string]string{}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		for _, item := range v {
+			k, val, ok := strings.Cut(fmt.Sprint(item), "=")
+			if !ok {
+				c.res.warnf("", p+"."+k, "values taken from the host are not supported")
+				continue
+			}
+			m[k] = val
+		}
+	case map[string]any:
+		for k, val := range v {
+			if val == nil {
+				c.res.warnf("", p+"."+k, "values taken from the host are not supported")
+				continue
+			}
+			m[k] = fmt.Sprint(val)
+		}
+	}
+	return m
+}
+
+// checkKeys は変換に対応していないサービスのキーを警告する
+// extra はそのサービスに限って変換に対応しているキー
+func (c *composeImporter) checkKeys(name string, svc map[string]any, extra ...string) {
+	handled := []string{"image", "build", "command", "entrypoint", "environment", "env_file", "depends_on", "deploy", "cpus", "mem_limit"}
+	handled = append(handled, silentKeys...)
+	handled = append(handled, extra...)
+	for _, key := range sortedKeys(svc) {
+		if !slices.Contains(handled, key) && !strings.HasPrefix(key, "x-") {
+			c.res.warnf("", "services."+name+"."+key, "%s is not supported", key)
+		}
+	}
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
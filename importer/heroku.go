@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// ErrNoProcesses は Procfile にプロセスが定義されていないことを表す
+var ErrNoProcesses = errors.New("Procfile defines no processes")
+
+// procfileLine は Procfile のプロセスの定義 (例: `web: bundle exec puma`) を表す
+var procfileLine = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.*)$`)
+
+// process は Procfile のプロセスを表す
+type process struct {
+	name    string
+	command string
+}
+
+// appJSON は app.json のうち変換に使うフィールドを表す
+// https://devcenter.heroku.com/articles/app-json-schema
+type appJSON struct {
+	Name      string                      `json:"name"`
+	Env       map[string]appJSONEnv       `json:"env"`
+	Formation map[string]appJSONFormation `json:"formation"`
+	Scripts   map[string]json.RawMessage  `json:"scripts"`
+
+	// 以下は AppConfig で表せないフィールド
+	Buildpacks   json.RawMessage `json:"buildpacks"`
+	Addons       json.RawMessage `json:"addons"`
+	Stack        json.RawMessage `json:"stack"`
+	Environments json.RawMessage `json:"environments"`
+}
+
+// appJSONEnv は app.json の環境変数を表す
+// 文字列またはオブジェクトで記述される
+type appJSONEnv struct {
+	Value     string `json:"value"`
+	Required  *bool  `json:"required"`
+	Generator string `json:"generator"`
+	hasValue  bool
+}
+
+func (e *appJSONEnv) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &e.Value); err == nil {
+		e.hasValue = true
+		return nil
+	}
+	type plain appJSONEnv
+	var v struct {
+		plain
+		Value *string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = appJSONEnv(v.plain)
+	if v.Value != nil {
+		e.Value, e.hasValue = *v.Value, true
+	}
+	return nil
+}
+
+// appJSONFormation は app.json のプロセスの台数とサイズを表す
+type appJSONFormation struct {
+	Quantity *int   `json:"quantity"`
+	Size     string `json:"size"`
+}
+
+// defaultMetric は台数が指定されたプロセスのスケーリングに使用するメトリクス
+var defaultMetric = apispec.ServiceMetricConfig{Type: "cpu", Threshold: 80}
+
+// Heroku は Procfile と app.json から AppConfig を作成する
+// appJSON が空の場合は Procfile のみから作成する
+//
+//   - `web` プロセスはサービスに、`release` プロセスはリリースに、それ以外のプロセスはワーカーに変換する
+//   - `web` プロセスがない場合は最初のプロセスをサービスにする
+//   - web プロセスは Options.Port で待ち受けるものとする
+//   - app.json の環境変数はすべてのプロセスに渡し、値のない環境変数はシークレットへの参照にする
+//   - buildpack によるビルドには対応しないため、Options.Image がない場合は Dockerfile からビルドする
+func Heroku(procfile, appJSONData []byte, opts Options) (*Result, error) {
+	opts = opts.withDefaults()
+	res := &Result{}
+	procs := parseProcfile(procfile, res)
+	if len(procs) == 0 {
+		return nil, ErrNoProcesses
+	}
+	var app appJSON
+	if len(bytes.TrimSpace(appJSONData)) > 0 {
+		if err := json.Unmarshal(appJSONData, &app); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", AppJSONName, err)
+		}
+	}
+
+	name := opts.appName(app.Name)
+	cfg := newConfig(name, opts)
+	if cfg.Build.Image == "" {
+		cfg.Build.Dockerfile = "Dockerfile"
+		res.warnf(ProcfileName, "", "buildpacks are not supported; build.dockerfile is set to Dockerfile")
+	}
+	env := herokuEnv(app, name, res)
+
+	web := slices.IndexFunc(procs, func(p process) bool { return p.name == "web" })
+	if web < 0 {
+		web = slices.IndexFunc(procs, func(p process) bool { return p.name != "release" })
+		if web < 0 {
+			return nil, fmt.Errorf("%w other than release", ErrNoProcesses)
+		}
+		res.warnf(ProcfileName, "", "no web process; %s is used as the service", procs[web].name)
+	}
+	for i, p := range procs {
+		command := splitCommand(p.command)
+		switch {
+		case i == web:
+			cfg.Service = apispec.ServiceConfig{
+				Name:    p.name,
+				Command: command,
+				HTTP:    []apispec.ServiceHTTPConfig{{TargetPort: opts.Port}},
+				Scale:   herokuScale(app, p.name, res),
+				Env:     env,
+			}
+		case p.name == "release":
+			cfg.Releases = append(cfg.Releases, apispec.ReleaseConfig{
+				Name:      p.name,
+				Resources: opts.Resources,
+				Action:    apispec.ReleaseActionConfig{Command: command},
+				Env:       env,
+			})
+		default:
+			cfg.Workers = append(cfg.Workers, apispec.WorkerConfig{
+				Name:    p.name,
+				Command: command,
+				Scale:   herokuScale(app, p.name, res),
+				Env:     env,
+			})
+		}
+	}
+	for _, name := range sortedKeys(app.Formation) {
+		if !slices.ContainsFunc(procs, func(p process) bool { return p.name == name }) {
+			res.warnf(AppJSONName, "formation."+name, "process %s is not defined in the Procfile", name)
+		}
+	}
+	for _, name := range sortedKeys(app.Scripts) {
+		res.warnf(AppJSONName, "scripts."+name, "scripts are not supported; add the command to releases if it must run on every deploy")
+	}
+	for _, f := range []struct {
+		name  string
+		value json.RawMessage
+	}{
+		{"buildpacks", app.Buildpacks},
+		{"addons", app.Addons},
+		{"stack", app.Stack},
+		{"environments", app.Environments},
+	} {
+		if len(f.value) > 0 && string(f.value) != "null" {
+			res.warnf(AppJSONName, f.name, "%s is not supported", f.name)
+		}
+	}
+	res.Config = cfg
+	return res, nil
+}
+
+// parseProcfile は Procfile のプロセスを定義された順に返す
+func parseProcfile(data []byte, res *Result) []process {
+	var procs []process
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := procfileLine.FindStringSubmatch(line)
+		if m == nil || strings.TrimSpace(m[2]) == "" {
+			res.warnf(ProcfileName, fmt.Sprintf("line %d", n), "malformed process definition %q", line)
+			continue
+		}
+		if slices.ContainsFunc(procs, func(p process) bool { return p.name == m[1] }) {
+			res.warnf(ProcfileName, fmt.Sprintf("line %d", n), "process %s is defined more than once; the first definition is used", m[1])
+			continue
+		}
+		procs = append(procs, process{name: m[1], command: m[2]})
+	}
+	return procs
+}
+
+// herokuEnv は app.json の環境変数を変換する
+// 値のない環境変数と generator で生成する環境変数は `secret://<app>/<NAME>` への参照にする
+func herokuEnv(app appJSON, appName string, res *Result) map[string]apispec.EnvValue {
+	if len(app.Env) == 0 {
+		return nil
+	}
+	env := make(map[string]apispec.EnvValue, len(app.Env))
+	for _, name := range sortedKeys(app.Env) {
+		e := app.Env[name]
+		if e.hasValue && e.Generator == "" {
+			env[name] = apispec.EnvValue(e.Value)
+			continue
+		}
+		ref := secretRef(appName, name)
+		env[name] = ref
+		res.warnf(AppJSONName, "env."+name, "no value is given; mapped to %s", ref)
+	}
+	return env
+}
+
+// herokuScale は app.json の formation をスケーリング設定に変換する
+// 台数は最小と最大の両方に設定し、メトリクスは defaultMetric を使用する
+func herokuScale(app appJSON, name string, res *Result) *apispec.ServiceScaleConfig {
+	f, ok := app.Formation[name]
+	if !ok {
+		return nil
+	}
+	path := "formation." + name
+	if f.Size != "" {
+		res.warnf(AppJSONName, path+".size", "dyno sizes are not supported; set machine_config instead")
+	}
+	if f.Quantity == nil || *f.Quantity <= 1 {
+		return nil
+	}
+	res.warnf(AppJSONName, path+".quantity", "mapped to scale with min and max %d and a %s metric of %d%%", *f.Quantity, defaultMetric.Type, defaultMetric.Threshold)
+	quantity := *f.Quantity
+	return &apispec.ServiceScaleConfig{Min: &quantity, Max: quantity, Metric: defaultMetric}
+}
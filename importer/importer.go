@@ -0,0 +1,226 @@
+// Package importer は既存のアプリケーションの定義から AppConfig を作成する
+//
+// Heroku の Procfile と app.json、docker-compose のファイルに対応する
+// 変換は可能な範囲で行い、AppConfig で表せない設定は Warning として報告する
+// 作成した AppConfig はバリデーションを通るとは限らないため、利用者が確認して補う必要がある
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// DefaultPort は Options.Port のデフォルト値
+const DefaultPort = 8080
+
+// DefaultResources は Options.Resources のデフォルト値
+var DefaultResources = apispec.ResourceConfig{
+	CPU:    apispec.MustParseQuantity("500m"),
+	Memory: apispec.MustParseQuantity("512Mi"),
+}
+
+// 読み込むファイルの名前
+const (
+	ProcfileName = "Procfile"
+	AppJSONName  = "app.json"
+)
+
+// ComposeFileNames は読み込む compose のファイル名を優先順に並べたもの
+var ComposeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// ErrNoSource は読み込めるファイルが見つからないことを表す
+var ErrNoSource = errors.New("no Procfile or compose file found")
+
+// Options は変換の方法を表す
+type Options struct {
+	// AppName はアプリケーションの名前
+	// 空の場合は app.json または compose のファイルの name を使用し、
+	// それもない場合は Load では読み込んだディレクトリの名前、Heroku と Compose では DefaultAppName を使用する
+	AppName string
+	// Image はコンテナイメージ
+	// 指定した場合は build.image に設定し、ビルドの設定は読み込まない
+	Image string
+	// Port はポートが分からない web プロセスの target_port
+	// 0 の場合は DefaultPort
+	Port int
+	// Resources はリソース量が分からないリリースやジョブのリソース設定
+	// ゼロ値の場合は DefaultResources
+	Resources apispec.ResourceConfig
+
+	// dirName は Load が読み込んだディレクトリの名前
+	dirName string
+}
+
+// DefaultAppName はアプリケーションの名前が分からない場合の名前
+const DefaultAppName = "app"
+
+// appName はアプリケーションの名前を返す
+// Options.AppName、ファイルに記述された name、Load が読み込んだディレクトリの名前、DefaultAppName の順に使う
+// シークレットへの参照にも使うため、変換を始める前に決めなければならない
+func (o Options) appName(name string) string {
+	for _, n := range []string{o.AppName, name, o.dirName} {
+		if n != "" {
+			return n
+		}
+	}
+	return DefaultAppName
+}
+
+func (o Options) withDefaults() Options {
+	if o.Port == 0 {
+		o.Port = DefaultPort
+	}
+	if !o.Resources.CPU.IsSet() && !o.Resources.Memory.IsSet() {
+		o.Resources = DefaultResources
+	}
+	return o
+}
+
+// Warning は AppConfig に変換できなかった設定を表す
+type Warning struct {
+	// File は設定のファイル名
+	File string
+	// Path はファイル上の設定の位置 (例: `services.web.volumes`)
+	// ファイル全体に関する警告の場合は空
+	Path string
+	// Message は利用者向けのメッセージ
+	Message string
+}
+
+func (w Warning) String() string {
+	var b strings.Builder
+	if w.File != "" {
+		b.WriteString(w.File + ": ")
+	}
+	if w.Path != "" {
+		b.WriteString(w.Path + ": ")
+	}
+	b.WriteString(w.Message)
+	return b.String()
+}
+
+// Result は変換の結果を表す
+type Result struct {
+	// Config は変換した設定
+	Config *apispec.AppConfig
+	// Warnings は変換できなかった設定の一覧
+	Warnings []Warning
+}
+
+func (r *Result) warnf(file, path, format string, args ...any) {
+	r.Warnings = append(r.Warnings, Warning{File: file, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Load は path のファイルを読み込んで変換する
+//
+// path がディレクトリの場合は compose のファイル、Procfile の順に探す
+// Procfile を読み込む場合は、同じディレクトリに app.json があればそれも読み込む
+// アプリケーションの名前が分からない場合はディレクトリの名前を使用する
+func Load(path string, opts Options) (*Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, file := path, ""
+	if !info.IsDir() {
+		dir, file = filepath.Dir(path), path
+	}
+	if file == "" {
+		if file, err = detect(dir); err != nil {
+			return nil, err
+		}
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	opts.dirName = filepath.Base(abs)
+
+	var res *Result
+	switch filepath.Base(file) {
+	case ProcfileName, AppJSONName:
+		res, err = loadHeroku(dir, opts)
+	default:
+		var data []byte
+		if data, err = os.ReadFile(file); err == nil {
+			res, err = Compose(data, opts)
+		}
+		if res != nil {
+			for i := range res.Warnings {
+				res.Warnings[i].File = file
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// detect は dir から読み込むファイルを探す
+func detect(dir string) (string, error) {
+	for _, name := range append(ComposeFileNames, ProcfileName) {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %w", dir, ErrNoSource)
+}
+
+func loadHeroku(dir string, opts Options) (*Result, error) {
+	procfile, err := os.ReadFile(filepath.Join(dir, ProcfileName))
+	if err != nil {
+		return nil, err
+	}
+	appJSON, err := os.ReadFile(filepath.Join(dir, AppJSONName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	res, err := Heroku(procfile, appJSON, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, w := range res.Warnings {
+		res.Warnings[i].File = filepath.Join(dir, w.File)
+	}
+	return res, nil
+}
+
+// shellChars はシェルによって解釈される文字を表す
+const shellChars = "$&|;<>()`*?~'\"\\{}[]!#\n"
+
+// splitCommand は s をコマンドの引数に分割する
+// シェルによって解釈される文字を含む場合は `sh -c` で実行するコマンドにする
+func splitCommand(s string) []string {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, shellChars) {
+		return []string{"sh", "-c", s}
+	}
+	return strings.Fields(s)
+}
+
+// newConfig は変換先の AppConfig を作成する
+func newConfig(name string, opts Options) *apispec.AppConfig {
+	cfg := &apispec.AppConfig{Version: apispec.LatestVersion, AppName: name, Releases: []apispec.ReleaseConfig{}}
+	if opts.Image != "" {
+		cfg.Build.Image = opts.Image
+	}
+	return cfg
+}
+
+// secretRef は appName の name という名前のシークレットへの参照を返す
+func secretRef(appName, name string) apispec.EnvValue {
+	return apispec.EnvValue("secret://" + appName + "/" + name)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
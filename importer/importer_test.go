@@ -0,0 +1,226 @@
+package importer
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// checkGolden は got を testdata の name のファイルと比較する
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run `go test ./importer -update` to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("result does not match %s (run `go test ./importer -update` to update it):\n%s", golden, got)
+	}
+}
+
+func TestLoad_Golden(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		path      string
+		golden    string
+		wantValid bool
+	}{
+		{
+			name:      "Procfileとapp.jsonから変換する",
+			path:      "testdata/heroku",
+			golden:    "heroku",
+			wantValid: true,
+		},
+		{
+			name:      "app.jsonがない場合、Procfileのみから変換し、名前はディレクトリ名にする",
+			path:      "testdata/procfile-only/Procfile",
+			golden:    "procfile-only",
+			wantValid: true,
+		},
+		{
+			name:      "composeのファイルから変換する",
+			path:      "testdata/compose",
+			golden:    "compose",
+			wantValid: true,
+		},
+		{
+			name:      "render/composeが生成したファイルを元の設定に戻す",
+			path:      "../render/compose/testdata/probes.golden.yaml",
+			golden:    "render-compose",
+			wantValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := Load(tt.path, Options{})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			cfg, err := apispec.Marshal(res.Config, apispec.FormatYAML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden+".golden.yaml", cfg)
+			var warnings strings.Builder
+			for _, w := range res.Warnings {
+				warnings.WriteString(filepath.ToSlash(w.String()) + "\n")
+			}
+			checkGolden(t, tt.golden+".warnings.golden", []byte(warnings.String()))
+
+			if err := res.Config.Validate(); (err == nil) != tt.wantValid {
+				t.Errorf("Validate() error = %v, want valid %v", err, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  error
+	}{
+		{name: "読み込めるファイルがない場合、ErrNoSourceを返す", files: map[string]string{"README.md": "# app"}, want: ErrNoSource},
+		{name: "Procfileにプロセスがない場合、ErrNoProcessesを返す", files: map[string]string{"Procfile": "# empty\n"}, want: ErrNoProcesses},
+		{name: "composeのファイルにサービスがない場合、ErrNoServicesを返す", files: map[string]string{"compose.yaml": "services: {}\n"}, want: ErrNoServices},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := Load(dir, Options{}); !errors.Is(err, tt.want) {
+				t.Errorf("Load() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Options(t *testing.T) {
+	t.Parallel()
+	res, err := Load("testdata/heroku", Options{AppName: "store", Image: "registry.example.com/store:v1", Port: 3000})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cfg := res.Config
+	if cfg.AppName != "store" {
+		t.Errorf("AppName = %q, want store", cfg.AppName)
+	}
+	if cfg.Build.Image != "registry.example.com/store:v1" || cfg.Build.Dockerfile != "" {
+		t.Errorf("Build = %+v, want the image only", cfg.Build)
+	}
+	if cfg.Service.HTTP[0].TargetPort != 3000 {
+		t.Errorf("target_port = %d, want 3000", cfg.Service.HTTP[0].TargetPort)
+	}
+	if cfg.Service.Env["SECRET_KEY_BASE"] != "secret://store/SECRET_KEY_BASE" {
+		t.Errorf("env SECRET_KEY_BASE = %q, want a reference to the store secret", cfg.Service.Env["SECRET_KEY_BASE"])
+	}
+	if slices.ContainsFunc(res.Warnings, func(w Warning) bool { return strings.Contains(w.Message, "buildpacks are not supported") }) {
+		t.Errorf("warns about buildpacks although the image is given: %v", res.Warnings)
+	}
+}
+
+func TestLoad_AppName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   string
+	}{
+		{
+			name:  "composeのファイルに名前がない場合、シークレットへの参照にもディレクトリ名を使う",
+			files: map[string]string{"compose.yaml": "services:\n  web:\n    image: web:latest\n    environment:\n      DATABASE_URL: ${DATABASE_URL}\n"},
+			env:   "DATABASE_URL",
+		},
+		{
+			name: "app.jsonに名前がない場合、シークレットへの参照にもディレクトリ名を使う",
+			files: map[string]string{
+				"Procfile": "web: bin/start\n",
+				"app.json": `{"env": {"SECRET_KEY_BASE": {"generator": "secret"}}}`,
+			},
+			env: "SECRET_KEY_BASE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := filepath.Join(t.TempDir(), "myshop")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			res, err := Load(dir, Options{})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if res.Config.AppName != "myshop" {
+				t.Errorf("AppName = %q, want myshop", res.Config.AppName)
+			}
+			if got, want := res.Config.Service.Env[tt.env], apispec.EnvValue("secret://myshop/"+tt.env); got != want {
+				t.Errorf("env %s = %q, want %q", tt.env, got, want)
+			}
+		})
+	}
+
+	t.Run("Loadを使わず名前も分からない場合、DefaultAppNameを使う", func(t *testing.T) {
+		t.Parallel()
+		res, err := Compose([]byte("services:\n  web:\n    image: web:latest\n    environment:\n      TOKEN:\n"), Options{})
+		if err != nil {
+			t.Fatalf("Compose() error = %v", err)
+		}
+		if res.Config.AppName != DefaultAppName || res.Config.Service.Env["TOKEN"] != "secret://app/TOKEN" {
+			t.Errorf("AppName = %q, env TOKEN = %q, want %s and its secret", res.Config.AppName, res.Config.Service.Env["TOKEN"], DefaultAppName)
+		}
+	})
+}
+
+func TestSplitCommand(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "bundle exec sidekiq", want: []string{"bundle", "exec", "sidekiq"}},
+		{command: "  node   server.js ", want: []string{"node", "server.js"}},
+		{command: "bundle exec puma -p $PORT", want: []string{"sh", "-c", "bundle exec puma -p $PORT"}},
+		{command: "bin/migrate && bin/seed", want: []string{"sh", "-c", "bin/migrate && bin/seed"}},
+		{command: `echo "hello world"`, want: []string{"sh", "-c", `echo "hello world"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			if got := splitCommand(tt.command); !slices.Equal(got, tt.want) {
+				t.Errorf("splitCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
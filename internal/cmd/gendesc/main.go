@@ -0,0 +1,151 @@
+// gendesc は apispec パッケージの構造体とフィールドのコメントを収集し、
+// JSON Schema の description として使うための Go ソースを生成する
+// 対象は root の型 (AppConfig) からフィールドをたどって到達できる型に限る
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+func main() {
+	out := flag.String("o", "descriptions_gen.go", "output file")
+	dir := flag.String("dir", ".", "package directory")
+	root := flag.String("root", "AppConfig", "type to collect descriptions from")
+	flag.Parse()
+
+	types, fields, err := collect(*dir, *out, *root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := render(types, fields)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// typeDecl は型の宣言とそのコメントを表す
+type typeDecl struct {
+	spec *ast.TypeSpec
+	doc  *ast.CommentGroup
+}
+
+// collect は dir のパッケージのうち root から到達できる型とその公開フィールドのコメントを収集する
+// テストファイルと出力先のファイルは対象外
+func collect(dir, out, root string) (types, fields map[string]string, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != out
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	decls := map[string]typeDecl{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					doc := ts.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+					decls[ts.Name.Name] = typeDecl{spec: ts, doc: doc}
+				}
+			}
+		}
+	}
+	if _, ok := decls[root]; !ok {
+		return nil, nil, fmt.Errorf("type %s not found in %s", root, dir)
+	}
+
+	types = map[string]string{}
+	fields = map[string]string{}
+	visited := map[string]bool{}
+	var visit func(expr ast.Expr)
+	visit = func(expr ast.Expr) {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			d, ok := decls[e.Name]
+			if !ok || visited[e.Name] {
+				return
+			}
+			visited[e.Name] = true
+			if text := commentText(d.doc); text != "" && e.IsExported() {
+				types[e.Name] = text
+			}
+			if st, ok := d.spec.Type.(*ast.StructType); ok {
+				for _, f := range st.Fields.List {
+					for _, name := range f.Names {
+						if text := commentText(f.Doc); text != "" && name.IsExported() {
+							fields[e.Name+"."+name.Name] = text
+						}
+					}
+					if len(f.Names) == 0 || slices.ContainsFunc(f.Names, (*ast.Ident).IsExported) {
+						visit(f.Type)
+					}
+				}
+			} else {
+				visit(d.spec.Type)
+			}
+		case *ast.StarExpr:
+			visit(e.X)
+		case *ast.ArrayType:
+			visit(e.Elt)
+		case *ast.MapType:
+			visit(e.Key)
+			visit(e.Value)
+		}
+	}
+	visit(ast.NewIdent(root))
+	return types, fields, nil
+}
+
+func commentText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cg.Text())
+}
+
+func render(types, fields map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gendesc. DO NOT EDIT.\n\n")
+	buf.WriteString("package apispec\n\n")
+	buf.WriteString("// typeDescriptions は型のコメントを表す\n")
+	writeMap(&buf, "typeDescriptions", types)
+	buf.WriteString("\n// fieldDescriptions は `型名.フィールド名` からフィールドのコメントへの対応を表す\n")
+	writeMap(&buf, "fieldDescriptions", fields)
+	return format.Source(buf.Bytes())
+}
+
+func writeMap(buf *bytes.Buffer, name string, m map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(buf, "var %s = map[string]string{\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "\t%q: %q,\n", k, m[k])
+	}
+	buf.WriteString("}\n")
+}
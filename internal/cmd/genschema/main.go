@@ -0,0 +1,32 @@
+// genschema は AppConfig の JSON Schema または OpenAPI ドキュメントをファイルに出力する
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+func main() {
+	out := flag.String("o", "schema/appconfig.schema.json", "output file")
+	openapi := flag.Bool("openapi", false, "output an OpenAPI 3.1 document instead of a JSON Schema")
+	flag.Parse()
+
+	generate := apispec.JSONSchema
+	if *openapi {
+		generate = apispec.OpenAPI
+	}
+	doc, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, doc, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,62 @@
+package apispec
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/robfig/cron/v3"
+)
+
+// CronJobConfig.ConcurrencyPolicy に指定できるポリシー
+const (
+	// ConcurrencyPolicyAllow は前回の実行が終わっていなくても次の実行を開始する
+	ConcurrencyPolicyAllow = "Allow"
+	// ConcurrencyPolicyForbid は前回の実行が終わっていない場合、次の実行をスキップする
+	ConcurrencyPolicyForbid = "Forbid"
+	// ConcurrencyPolicyReplace は前回の実行を停止してから次の実行を開始する
+	ConcurrencyPolicyReplace = "Replace"
+)
+
+// validateCron は `cron` ルールを実装する
+// 値が CronExpression で cron 式に変換できるスケジュールであることを検証する
+func validateCron(fl validator.FieldLevel) bool {
+	_, err := CronExpression(fl.Field().String())
+	return err == nil
+}
+
+// CronExpression は schedule を Kubernetes の CronJob などが解釈できる5フィールドの cron 式、
+// または `@hourly` のような記述子に変換する
+// `@every` は1時間または1日を割り切れる間隔の場合のみ cron 式に変換でき、それ以外の場合はエラーを返す
+// タイムゾーンの指定 (`TZ=` や `CRON_TZ=`) はサポートしない
+func CronExpression(schedule string) (string, error) {
+	if strings.HasPrefix(schedule, "TZ=") || strings.HasPrefix(schedule, "CRON_TZ=") {
+		return "", fmt.Errorf("invalid schedule %q: time zones are not supported", schedule)
+	}
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return "", fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	every, ok := strings.CutPrefix(schedule, "@every ")
+	if !ok {
+		return schedule, nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(every))
+	if err != nil {
+		return "", fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	switch {
+	case d == time.Minute:
+		return "* * * * *", nil
+	case d > 0 && d%time.Minute == 0 && d < time.Hour && time.Hour%d == 0:
+		return fmt.Sprintf("*/%d * * * *", d/time.Minute), nil
+	case d == time.Hour:
+		return "0 * * * *", nil
+	case d > 0 && d%time.Hour == 0 && d < 24*time.Hour && 24*time.Hour%d == 0:
+		return fmt.Sprintf("0 */%d * * *", d/time.Hour), nil
+	case d == 24*time.Hour:
+		return "0 0 * * *", nil
+	default:
+		return "", fmt.Errorf("schedule %q cannot be expressed as a cron expression: the interval must divide an hour or a day", schedule)
+	}
+}
@@ -0,0 +1,174 @@
+package apispec
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadFile_Processes(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFile("testdata/processes.yaml")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(cfg.Workers) != 1 || cfg.Workers[0].Scale.Max != 3 {
+		t.Errorf("Workers = %+v, want the mailer worker", cfg.Workers)
+	}
+	if len(cfg.Jobs) != 2 {
+		t.Fatalf("Jobs = %+v, want 2 jobs", cfg.Jobs)
+	}
+	if got := cfg.Jobs[0].Timeout.Std(); got != 30*time.Minute {
+		t.Errorf("Jobs[0].Timeout = %v, want 30m", got)
+	}
+	// ConcurrencyPolicy を省略した場合は Allow になる
+	if got := cfg.Jobs[1].ConcurrencyPolicy; got != ConcurrencyPolicyAllow {
+		t.Errorf("Jobs[1].ConcurrencyPolicy = %q, want %q", got, ConcurrencyPolicyAllow)
+	}
+}
+
+func TestCronJobConfig_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		modify   func(j *CronJobConfig)
+		wantPath string
+		wantRule string
+	}{
+		{
+			name:   "正しいジョブの場合、エラーにならない",
+			modify: func(j *CronJobConfig) {},
+		},
+		{
+			name:   "記述子のスケジュールの場合、エラーにならない",
+			modify: func(j *CronJobConfig) { j.Schedule = "@daily" },
+		},
+		{
+			name:     "スケジュールのフィールドが足りない場合、エラーになる",
+			modify:   func(j *CronJobConfig) { j.Schedule = "0 3 * *" },
+			wantPath: "schedule",
+			wantRule: "cron",
+		},
+		{
+			name:     "@everyの間隔が1時間を割り切れない場合、エラーになる",
+			modify:   func(j *CronJobConfig) { j.Schedule = "@every 7m" },
+			wantPath: "schedule",
+			wantRule: "cron",
+		},
+		{
+			name:     "スケジュールの値が範囲外の場合、エラーになる",
+			modify:   func(j *CronJobConfig) { j.Schedule = "0 25 * * *" },
+			wantPath: "schedule",
+			wantRule: "cron",
+		},
+		{
+			name:     "ConcurrencyPolicyが不正な場合、エラーになる",
+			modify:   func(j *CronJobConfig) { j.ConcurrencyPolicy = "Queue" },
+			wantPath: "concurrency_policy",
+			wantRule: "oneof",
+		},
+		{
+			name:     "Timeoutが負の場合、エラーになる",
+			modify:   func(j *CronJobConfig) { j.Timeout = NewDuration(-time.Minute) },
+			wantPath: "timeout",
+			wantRule: "duration",
+		},
+		{
+			name:     "Resourcesが設定されていない場合、エラーになる",
+			modify:   func(j *CronJobConfig) { j.Resources = ResourceConfig{} },
+			wantPath: "resources.cpu",
+			wantRule: "required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			job := CronJobConfig{
+				Name:              "cleanup",
+				Schedule:          "0 3 * * *",
+				Command:           []string{"npm", "run", "cleanup"},
+				Resources:         ResourceConfig{CPU: MustParseQuantity("250m"), Memory: MustParseQuantity("128Mi")},
+				ConcurrencyPolicy: ConcurrencyPolicyForbid,
+				Timeout:           MustParseDuration("30m"),
+			}
+			tt.modify(&job)
+			err := validateStruct(job)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Errorf("validateStruct() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("validateStruct() error = %v, want ValidationErrors", err)
+			}
+			if errs[0].Path != tt.wantPath || errs[0].Rule != tt.wantRule {
+				t.Errorf("ValidationError = %+v, want path %q and rule %q", errs[0], tt.wantPath, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestCronExpression(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		schedule string
+		want     string
+		wantErr  bool
+	}{
+		{schedule: "0 3 * * *", want: "0 3 * * *"},
+		{schedule: "@daily", want: "@daily"},
+		{schedule: "@every 1m", want: "* * * * *"},
+		{schedule: "@every 15m", want: "*/15 * * * *"},
+		{schedule: "@every 1h", want: "0 * * * *"},
+		{schedule: "@every 6h", want: "0 */6 * * *"},
+		{schedule: "@every 24h", want: "0 0 * * *"},
+		{schedule: "@every 90s", wantErr: true},
+		{schedule: "@every 7m", wantErr: true},
+		{schedule: "@every 48h", wantErr: true},
+		{schedule: "TZ=Asia/Tokyo 0 3 * * *", wantErr: true},
+		{schedule: "0 25 * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			t.Parallel()
+			got, err := CronExpression(tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CronExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CronExpression() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppConfig_Validate_ProcessNames(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFile("testdata/processes.yaml")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	cfg.Workers = append(cfg.Workers, WorkerConfig{Name: "web", Command: []string{"npm", "run", "worker"}})
+	cfg.Jobs[1].Name = "mailer"
+
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) {
+		t.Fatalf("Validate() did not return ValidationErrors")
+	}
+	want := []ValidationError{
+		{Path: "workers[1].name", Param: "service.name"},
+		{Path: "jobs[1].name", Param: "workers[0].name"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, w := range want {
+		if errs[i].Path != w.Path || errs[i].Rule != "unique" || errs[i].Param != w.Param {
+			t.Errorf("errs[%d] = %+v, want %+v", i, errs[i], w)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format は設定ファイルの形式を表す
+type Format int
+
+const (
+	// FormatAuto は内容から形式を判定する
+	FormatAuto Format = iota
+	// FormatYAML はYAML形式を表す
+	FormatYAML
+	// FormatJSON はJSON形式を表す
+	FormatJSON
+)
+
+// String は形式の名前を返す
+func (f Format) String() string {
+	switch f {
+	case FormatYAML:
+		return "yaml"
+	case FormatJSON:
+		return "json"
+	default:
+		return "auto"
+	}
+}
+
+// FormatFromPath はファイルの拡張子から形式を判定する
+// 判定できない場合は FormatAuto を返す
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	default:
+		return FormatAuto
+	}
+}
+
+// detectFormat は内容から形式を判定する
+// 先頭の空白を除いた最初の文字が `{` であればJSON、それ以外はYAMLとみなす
+func detectFormat(data []byte) Format {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// LoadError は設定ファイルの読み込みに失敗したことを表す
+type LoadError struct {
+	// File は読み込んだファイルのパス
+	// io.Reader から読み込んだ場合は空
+	File string
+	// Err は構文エラーまたはバリデーションエラー
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	if e.File == "" {
+		return e.Err.Error()
+	}
+	return e.File + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// ErrEmptyDocument は設定ファイルが空であることを表す
+var ErrEmptyDocument = errors.New("document is empty")
+
+// LoadOption は設定ファイルの読み込み方法を変更するオプションを表す
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	strict bool
+	// versions は読み込むことができる設定ファイルの形式のバージョン
+	// nil の場合は schemaVersions
+	versions versionRegistry
+}
+
+// WithStrict は未知のキーをエラーとして扱う
+// エラーは UnknownFieldErrors として報告され、近い既知のキー名が提案される
+func WithStrict() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+// Document は読み込んだ設定と、設定ファイル上の位置情報を表す
+type Document struct {
+	// Config はデコードした設定
+	Config *AppConfig
+	// Positions は設定ファイル上のパスから位置への索引
+	Positions PositionIndex
+	// Version は設定ファイルに記述されていたバージョン
+	// LatestVersion でない場合、Config は LatestVersion の形式に移行した設定を表す
+	Version string
+}
+
+// LoadFile は path の設定ファイルを読み込み、デフォルト値を適用したバリデーション済みの AppConfig を返す
+// 形式は拡張子から判定し、判定できない場合は内容から判定する
+// 古いバージョンの設定ファイルは LatestVersion の形式に移行してから読み込む
+func LoadFile(path string, opts ...LoadOption) (*AppConfig, error) {
+	doc, err := LoadDocumentFile(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Config, nil
+}
+
+// Load は r から設定を読み込み、デフォルト値を適用したバリデーション済みの AppConfig を返す
+// format に FormatAuto を指定した場合は内容から形式を判定する
+func Load(r io.Reader, format Format, opts ...LoadOption) (*AppConfig, error) {
+	doc, err := LoadDocument(r, format, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Config, nil
+}
+
+// LoadDocumentFile は LoadFile と同様に設定ファイルを読み込み、位置情報とともに返す
+func LoadDocumentFile(path string, opts ...LoadOption) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := load(path, data, FormatFromPath(path), opts)
+	if err != nil {
+		return nil, &LoadError{File: path, Err: err}
+	}
+	return doc, nil
+}
+
+// LoadDocument は Load と同様に設定を読み込み、位置情報とともに返す
+func LoadDocument(r io.Reader, format Format, opts ...LoadOption) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := load("", data, format, opts)
+	if err != nil {
+		return nil, &LoadError{Err: err}
+	}
+	return doc, nil
+}
+
+func load(file string, data []byte, format Format, opts []LoadOption) (*Document, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.versions == nil {
+		o.versions = schemaVersions
+	}
+	if format == FormatAuto {
+		format = detectFormat(data)
+	}
+	root, err := parse(data, format)
+	if err != nil {
+		return nil, err
+	}
+	version, err := documentVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	from, err := o.versions.lookup(version)
+	if err != nil {
+		return nil, err
+	}
+	var unknown error
+	if o.strict {
+		// 未知のキーは移行前の形式で検出するため、移行前のパスで位置を引く
+		if errs := checkUnknownFields(root, o.versions[from].typ); len(errs) > 0 {
+			idx := buildPositionIndex(file, root)
+			for _, e := range errs {
+				e.Position = idx[e.Path]
+			}
+			unknown = errs
+		}
+	}
+	if err := o.versions.migrate(root, version); err != nil {
+		return nil, errors.Join(unknown, err)
+	}
+	// 移行によって移動したノードも元の位置を保持しているため、移行後のパスから元の位置を引ける
+	idx := buildPositionIndex(file, root)
+	var cfg AppConfig
+	if err := root.Decode(&cfg); err != nil {
+		return nil, errors.Join(unknown, fmt.Errorf("failed to decode %s: %w", format, err))
+	}
+	cfg.ApplyDefaults()
+	if err := errors.Join(unknown, withPositions(cfg.Validate(), idx)); err != nil {
+		return nil, err
+	}
+	return &Document{Config: &cfg, Positions: idx, Version: version}, nil
+}
+
+// parse は data を構文木に変換し、トップレベルのマップを返す
+func parse(data []byte, format Format) (*yaml.Node, error) {
+	doc, err := parseDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Content[0], nil
+}
+
+// parseDocument は data を構文木に変換し、ファイル全体のコメントを持つ DocumentNode を返す
+// JSONはYAMLのサブセットとして扱うが、JSONとして不正な入力は事前に弾く
+func parseDocument(data []byte, format Format) (*yaml.Node, error) {
+	if format == FormatJSON && !json.Valid(data) {
+		var v any
+		err := json.Unmarshal(data, &v)
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", format, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse %s: top-level value must be a mapping", format)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,130 @@
+package apispec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		path string
+	}{
+		{
+			name: "YAMLファイルを読み込める",
+			path: "testdata/valid.yaml",
+		},
+		{
+			name: "JSONファイルを読み込める",
+			path: "testdata/valid.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := LoadFile(tt.path)
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if cfg.AppName != "myapp" {
+				t.Errorf("AppName = %q, want %q", cfg.AppName, "myapp")
+			}
+			if len(cfg.Service.HTTP) != 1 || cfg.Service.HTTP[0].TargetPort != 8080 {
+				t.Errorf("Service.HTTP = %+v, want target_port 8080", cfg.Service.HTTP)
+			}
+		})
+	}
+}
+
+func TestLoadFile_NotFound(t *testing.T) {
+	t.Parallel()
+	_, err := LoadFile("testdata/not-found.yaml")
+	if err == nil {
+		t.Fatal("LoadFile() error = nil, want error")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		input          string
+		format         Format
+		wantErr        bool
+		wantValidation bool
+	}{
+		{
+			name: "形式を指定せずにYAMLを読み込める",
+			input: `
+app_name: myapp
+build: {image: myapp:latest}
+releases: [{name: r, resources: {cpu: 500m, memory: 256Mi}, action: {command: [echo]}}]
+service: {name: web, command: [npm, start]}
+`,
+			format: FormatAuto,
+		},
+		{
+			name:   "形式を指定せずにJSONを読み込める",
+			input:  `{"app_name":"myapp","build":{"image":"myapp:latest"},"releases":[{"name":"r","resources":{"cpu":"500m","memory":"256Mi"},"action":{"command":["echo"]}}],"service":{"name":"web","command":["npm","start"]}}`,
+			format: FormatAuto,
+		},
+		{
+			name:    "JSONとして不正な場合、エラーになる",
+			input:   `{"app_name": "myapp",}`,
+			format:  FormatJSON,
+			wantErr: true,
+		},
+		{
+			name:    "YAMLとして不正な場合、エラーになる",
+			input:   "app_name: [myapp",
+			format:  FormatYAML,
+			wantErr: true,
+		},
+		{
+			name:    "トップレベルがマッピングでない場合、エラーになる",
+			input:   "- myapp",
+			format:  FormatYAML,
+			wantErr: true,
+		},
+		{
+			name:    "空の場合、エラーになる",
+			input:   "",
+			format:  FormatYAML,
+			wantErr: true,
+		},
+		{
+			name:           "必須フィールドが欠けている場合、バリデーションエラーになる",
+			input:          "app_name: myapp\n",
+			format:         FormatYAML,
+			wantErr:        true,
+			wantValidation: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := Load(strings.NewReader(tt.input), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var loadErr *LoadError
+				if !errors.As(err, &loadErr) {
+					t.Errorf("Load() error = %T, want *LoadError", err)
+				}
+				var verrs ValidationErrors
+				if got := errors.As(err, &verrs); got != tt.wantValidation {
+					t.Errorf("errors.As(ValidationErrors) = %v, want %v", got, tt.wantValidation)
+				}
+				return
+			}
+			if cfg.AppName != "myapp" {
+				t.Errorf("AppName = %q, want %q", cfg.AppName, "myapp")
+			}
+		})
+	}
+}
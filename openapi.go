@@ -0,0 +1,89 @@
+package apispec
+
+import (
+	"bytes"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion は OpenAPI が出力するドキュメントのバージョン
+const OpenAPIVersion = "3.1.0"
+
+// openAPIDocument は OpenAPI ドキュメントのうち、出力に必要な部分を表す
+// キーの順序を固定するため構造体で定義する
+type openAPIDocument struct {
+	OpenAPI           string            `yaml:"openapi"`
+	Info              openAPIInfo       `yaml:"info"`
+	JSONSchemaDialect string            `yaml:"jsonSchemaDialect"`
+	Tags              []openAPITag      `yaml:"tags"`
+	Paths             map[string]any    `yaml:"paths"`
+	Components        openAPIComponents `yaml:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string         `yaml:"title"`
+	Description string         `yaml:"description"`
+	Version     string         `yaml:"version"`
+	Contact     openAPIContact `yaml:"contact"`
+	License     openAPILicense `yaml:"license"`
+}
+
+type openAPIContact struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+type openAPILicense struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+type openAPITag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]any `yaml:"schemas"`
+}
+
+// OpenAPI は AppConfig とその構成要素を components.schemas に持つ OpenAPI 3.1 ドキュメントを YAML で返す
+// スキーマは JSONSchema と同じく Go の型から生成される
+// API を定義しないドキュメントのため paths と servers は持たない
+func OpenAPI() ([]byte, error) {
+	b := newSchemaBuilder("#/components/schemas/")
+	b.ref(reflect.TypeFor[AppConfig]())
+	doc := openAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info: openAPIInfo{
+			Title:       "tacokumo appconfig",
+			Description: "アプリケーションの設定ファイル (AppConfig) のスキーマ定義",
+			Version:     "1.0.0",
+			Contact: openAPIContact{
+				Name: "tacokumo",
+				URL:  "https://github.com/tacokumo/appconfig",
+			},
+			License: openAPILicense{
+				Name: "MIT",
+				URL:  "https://github.com/tacokumo/appconfig/blob/main/LICENSE",
+			},
+		},
+		JSONSchemaDialect: JSONSchemaDialect,
+		Tags:              []openAPITag{{Name: "appconfig", Description: "アプリケーションの設定ファイル"}},
+		Paths:             map[string]any{},
+		Components: openAPIComponents{
+			Schemas: b.defs,
+		},
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,141 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+func TestOpenAPI_Stable(t *testing.T) {
+	t.Parallel()
+	first, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+	for range 5 {
+		again, err := OpenAPI()
+		if err != nil {
+			t.Fatalf("OpenAPI() error = %v", err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatal("OpenAPI() output is not deterministic")
+		}
+	}
+	committed, err := os.ReadFile("schema/openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(committed, first) {
+		t.Error("schema/openapi.yaml is out of date, run `go generate ./...`")
+	}
+}
+
+func TestOpenAPI_Components(t *testing.T) {
+	t.Parallel()
+	b, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+	var doc struct {
+		OpenAPI    string `yaml:"openapi"`
+		Components struct {
+			Schemas map[string]any `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q, want %q", doc.OpenAPI, "3.1.0")
+	}
+	for _, name := range []string{"AppConfig", "BuildConfig", "ReleaseConfig", "ServiceConfig", "StageConfig", "Quantity"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("components.schemas.%s is missing", name)
+		}
+	}
+
+	// すべての参照が components.schemas 内の定義を指し、AppConfig 以外のすべての定義が参照されている
+	referenced := map[string]bool{}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name, found := strings.CutPrefix(ref, "#/components/schemas/")
+				if _, exists := doc.Components.Schemas[name]; !found || !exists {
+					t.Errorf("$ref %q does not resolve", ref)
+				}
+				referenced[name] = true
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc.Components.Schemas)
+	for name := range doc.Components.Schemas {
+		if name != "AppConfig" && !referenced[name] {
+			t.Errorf("components.schemas.%s is not referenced", name)
+		}
+	}
+}
+
+func TestOpenAPI_Validate(t *testing.T) {
+	t.Parallel()
+	b, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+
+	// OpenAPI 3.1 のドキュメントとして必須のフィールドを持つ
+	info, _ := doc["info"].(map[string]any)
+	if info["title"] == nil || info["version"] == nil {
+		t.Errorf("info = %v, want title and version", info)
+	}
+	if doc["jsonSchemaDialect"] != JSONSchemaDialect {
+		t.Errorf("jsonSchemaDialect = %v, want %q", doc["jsonSchemaDialect"], JSONSchemaDialect)
+	}
+
+	// components.schemas.AppConfig は JSON Schema としてコンパイルでき、設定ファイルを検証できる
+	j, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := jsonschema.UnmarshalJSON(bytes.NewReader(j))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	if err := c.AddResource("openapi.json", res); err != nil {
+		t.Fatalf("AddResource() error = %v", err)
+	}
+	schema, err := c.Compile("openapi.json#/components/schemas/AppConfig")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	valid, err := os.ReadFile("testdata/valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(yamlToJSONValue(t, string(valid))); err != nil {
+		t.Errorf("Validate(testdata/valid.yaml) error = %v", err)
+	}
+	invalid := strings.Replace(string(valid), "256Mi", "256MB", 1)
+	if err := schema.Validate(yamlToJSONValue(t, invalid)); err == nil {
+		t.Error("Validate() with an invalid quantity error = nil, want error")
+	}
+}
@@ -0,0 +1,163 @@
+package apispec
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ErrStageNotFound は指定した名前のステージが存在しないことを表す
+var ErrStageNotFound = errors.New("stage not found")
+
+// ResolvedConfig はステージの上書き設定を適用した設定を表す
+type ResolvedConfig struct {
+	// Stage は対象のステージ
+	Stage StageConfig
+	// Config は上書き設定を適用した AppConfig
+	Config AppConfig
+}
+
+// ForStage は name のステージの上書き設定を適用し、バリデーション済みの設定を返す
+// ステージが定義されていない場合は ApplyDefaults と同様にデフォルトのステージを対象とする
+// 返す設定は c のマップやスライスを共有しないため、変更しても c には影響しない
+//
+// 上書きは次の規則でマージされる
+//
+//   - マップ (build.build_args, service.env) はキーごとにマージし、同じキーはステージの値を優先する
+//   - リスト (releases, service.command) はステージの値で置き換える
+//   - ブロック (service.scale, service.machine_config) は指定したフィールドのみを上書きする
+//     ただし service.scale.metric はブロック全体を置き換える
+func (c *AppConfig) ForStage(name string) (*ResolvedConfig, error) {
+	cfg := deepCopy(*c)
+	cfg.ApplyDefaults()
+
+	idx := slices.IndexFunc(cfg.Stages, func(s StageConfig) bool { return s.Name == name })
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrStageNotFound, name)
+	}
+	stage := cfg.Stages[idx]
+	if o := stage.Overrides; o != nil {
+		if o.Build != nil {
+			cfg.Build = mergeBuild(cfg.Build, o.Build)
+		}
+		if o.Service != nil {
+			cfg.Service = mergeService(cfg.Service, o.Service)
+		}
+		if o.Releases != nil {
+			cfg.Releases = slices.Clone(o.Releases)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("stage %q: %w", name, err)
+	}
+	return &ResolvedConfig{Stage: stage, Config: cfg}, nil
+}
+
+// deepCopy は v のポインタ、スライス、マップをたどって複製した値を返す
+func deepCopy[T any](v T) T {
+	return deepCopyValue(reflect.ValueOf(v)).Interface().(T)
+}
+
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(deepCopyValue(v.Elem()))
+		return p
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			s.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return m
+	case reflect.Struct:
+		// 非公開のフィールド (Quantity の値など) はそのまま複製し、公開されたフィールドのみをたどる
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				s.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func mergeBuild(base BuildConfig, o *BuildOverridesConfig) BuildConfig {
+	base.BuildArgs = mergeMap(base.BuildArgs, o.BuildArgs)
+	return base
+}
+
+// mergeMap は base に o をキーごとにマージした新しいマップを返す
+// o が空の場合は base をそのまま返す
+func mergeMap[V any](base, o map[string]V) map[string]V {
+	if len(o) == 0 {
+		return base
+	}
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]V, len(o))
+	}
+	maps.Copy(merged, o)
+	return merged
+}
+
+func mergeService(base ServiceConfig, o *ServiceOverridesConfig) ServiceConfig {
+	if o.Command != nil {
+		base.Command = slices.Clone(o.Command)
+	}
+	if o.Scale != nil {
+		var scale ServiceScaleConfig
+		if base.Scale != nil {
+			scale = *base.Scale
+		}
+		if o.Scale.Min != nil {
+			minInstances := *o.Scale.Min
+			scale.Min = &minInstances
+		}
+		if o.Scale.Max != nil {
+			scale.Max = *o.Scale.Max
+		}
+		if o.Scale.Metric != nil {
+			scale.Metric = *o.Scale.Metric
+		}
+		base.Scale = &scale
+	}
+	if o.MachineConfig != nil {
+		var machine MachineConfig
+		if base.MachineConfig != nil {
+			machine = *base.MachineConfig
+		}
+		if o.MachineConfig.CPU.IsSet() {
+			machine.CPU = o.MachineConfig.CPU
+		}
+		if o.MachineConfig.Memory.IsSet() {
+			machine.Memory = o.MachineConfig.Memory
+		}
+		if o.MachineConfig.Flavor != "" {
+			machine.Flavor = o.MachineConfig.Flavor
+		}
+		base.MachineConfig = &machine
+	}
+	base.Env = mergeMap(base.Env, o.Env)
+	return base
+}
@@ -0,0 +1,181 @@
+package apispec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAppConfig_ForStage(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFile("testdata/stages.yaml")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	t.Run("上書き設定がない場合、元の設定と等しくなる", func(t *testing.T) {
+		t.Parallel()
+		got, err := cfg.ForStage("production")
+		if err != nil {
+			t.Fatalf("ForStage() error = %v", err)
+		}
+		if got.Stage.Name != "production" {
+			t.Errorf("Stage.Name = %q, want %q", got.Stage.Name, "production")
+		}
+		if !reflect.DeepEqual(got.Config, *cfg) {
+			t.Errorf("Config = %+v, want %+v", got.Config, *cfg)
+		}
+	})
+
+	t.Run("上書き設定が規則に従ってマージされる", func(t *testing.T) {
+		t.Parallel()
+		got, err := cfg.ForStage("staging")
+		if err != nil {
+			t.Fatalf("ForStage() error = %v", err)
+		}
+		// マップはキーごとにマージされる
+		wantArgs := map[string]string{"NODE_ENV": "production", "LOG_LEVEL": "debug"}
+		if !reflect.DeepEqual(got.Config.Build.BuildArgs, wantArgs) {
+			t.Errorf("Build.BuildArgs = %v, want %v", got.Config.Build.BuildArgs, wantArgs)
+		}
+		wantEnv := map[string]EnvValue{
+			"APP_NAME":          "field://app_name",
+			"DATABASE_PASSWORD": "secret://database/password",
+			"LOG_FORMAT":        "text",
+		}
+		if !reflect.DeepEqual(got.Config.Service.Env, wantEnv) {
+			t.Errorf("Service.Env = %v, want %v", got.Config.Service.Env, wantEnv)
+		}
+		// ブロックは指定したフィールドのみが上書きされる
+		wantScale := ServiceScaleConfig{Min: ptr(0), Max: 2, Metric: ServiceMetricConfig{Type: "cpu", Threshold: 70}}
+		if !reflect.DeepEqual(*got.Config.Service.Scale, wantScale) {
+			t.Errorf("Service.Scale = %+v, want %+v", *got.Config.Service.Scale, wantScale)
+		}
+		if m := got.Config.Service.MachineConfig; m.CPU.String() != "1" || m.Memory.String() != "512Mi" {
+			t.Errorf("Service.MachineConfig = %+v, want cpu 1 and memory 512Mi", *m)
+		}
+		// リストは置き換えられる
+		if len(got.Config.Releases) != 1 || got.Config.Releases[0].Resources.CPU.String() != "250m" {
+			t.Errorf("Releases = %+v, want the staging releases", got.Config.Releases)
+		}
+	})
+
+	t.Run("元の設定は変更されない", func(t *testing.T) {
+		t.Parallel()
+		if _, err := cfg.ForStage("staging"); err != nil {
+			t.Fatalf("ForStage() error = %v", err)
+		}
+		if got := cfg.Build.BuildArgs["LOG_LEVEL"]; got != "info" {
+			t.Errorf("Build.BuildArgs[LOG_LEVEL] = %q, want %q", got, "info")
+		}
+		if got := cfg.Service.Scale.Max; got != 10 {
+			t.Errorf("Service.Scale.Max = %d, want 10", got)
+		}
+	})
+
+	t.Run("存在しないステージの場合、エラーになる", func(t *testing.T) {
+		t.Parallel()
+		if _, err := cfg.ForStage("preview"); !errors.Is(err, ErrStageNotFound) {
+			t.Errorf("ForStage() error = %v, want ErrStageNotFound", err)
+		}
+	})
+}
+
+func TestAppConfig_ForStage_DoesNotShare(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"production", "staging"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := LoadFile("testdata/stages.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := LoadFile("testdata/stages.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := cfg.ForStage(name)
+			if err != nil {
+				t.Fatalf("ForStage() error = %v", err)
+			}
+			// 返された設定を変更しても元の設定は変わらない
+			got.Config.Build.BuildArgs["NODE_ENV"] = "test"
+			got.Config.Service.Env["LOG_FORMAT"] = "logfmt"
+			got.Config.Service.Command[0] = "yarn"
+			*got.Config.Service.Scale.Min = 5
+			got.Config.Releases[0].Action.Command[0] = "bin/rollback"
+			got.Stage.Policy.Branch.Name = "feature/*"
+			if o := got.Stage.Overrides; o != nil {
+				o.Releases[0].Name = "seed"
+				o.Build.BuildArgs["LOG_LEVEL"] = "warn"
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("ForStage() modified the original config:\n%+v\nwant\n%+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestAppConfig_ForStage_DefaultStage(t *testing.T) {
+	t.Parallel()
+	cfg := AppConfig{
+		Version:  LatestVersion,
+		AppName:  "myapp",
+		Build:    BuildConfig{Image: "myapp:latest"},
+		Releases: []ReleaseConfig{},
+		Service:  ServiceConfig{Name: "web", Command: []string{"npm", "start"}},
+	}
+	got, err := cfg.ForStage(DefaultStageName)
+	if err != nil {
+		t.Fatalf("ForStage() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Stage, DefaultStage()) {
+		t.Errorf("Stage = %+v, want %+v", got.Stage, DefaultStage())
+	}
+	if cfg.Stages != nil {
+		t.Errorf("Stages = %+v, want nil", cfg.Stages)
+	}
+}
+
+func TestAppConfig_ForStage_Invalid(t *testing.T) {
+	t.Parallel()
+	maxInstances := 1
+	cfg := AppConfig{
+		Version:  LatestVersion,
+		AppName:  "myapp",
+		Build:    BuildConfig{Image: "myapp:latest"},
+		Releases: []ReleaseConfig{},
+		Service: ServiceConfig{
+			Name:    "web",
+			Command: []string{"npm", "start"},
+			Scale:   &ServiceScaleConfig{Min: ptr(2), Max: 5, Metric: ServiceMetricConfig{Type: "cpu", Threshold: 80}},
+		},
+		Stages: []StageConfig{
+			{
+				Name:   "staging",
+				Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "develop"}},
+				Overrides: &StageOverridesConfig{
+					Build:   &BuildOverridesConfig{BuildArgs: map[string]string{"A": "1"}},
+					Service: &ServiceOverridesConfig{Scale: &ServiceScaleOverridesConfig{Max: &maxInstances}},
+				},
+			},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	_, err := cfg.ForStage("staging")
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ForStage() error = %v, want ValidationErrors", err)
+	}
+	paths := make([]string, len(errs))
+	for i, e := range errs {
+		paths[i] = e.Path
+	}
+	// Image に build_args は指定できず、max が min を下回る
+	want := []string{"build.build_args", "service.scale.max"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,99 @@
+package apispec
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position は設定ファイル上の位置を表す
+type Position struct {
+	// File はファイルのパス
+	// io.Reader から読み込んだ場合は空
+	File string
+	// Line は1始まりの行番号
+	// 位置が不明な場合は0
+	Line int
+	// Column は1始まりの列番号
+	Column int
+}
+
+// IsValid は位置が既知かどうかを返す
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String は `file:line:column` 形式の文字列を返す
+func (p Position) String() string {
+	var b strings.Builder
+	b.WriteString(p.File)
+	if p.IsValid() {
+		if b.Len() > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(strconv.Itoa(p.Line))
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(p.Column))
+	}
+	return b.String()
+}
+
+// PositionIndex は設定ファイル上のパス (例: `releases[0].resources.cpu`) から位置への対応を表す
+type PositionIndex map[string]Position
+
+// Lookup は path の位置を返す
+// path が設定ファイルに存在しない場合は、存在する最も近い祖先の位置を返す
+func (idx PositionIndex) Lookup(path string) (Position, bool) {
+	for {
+		if pos, ok := idx[path]; ok {
+			return pos, true
+		}
+		if path == "" {
+			return Position{}, false
+		}
+		path = parentPath(path)
+	}
+}
+
+// parentPath は path の親のパスを返す
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndexByte(path, '['); i >= 0 {
+			return path[:i]
+		}
+	}
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// buildPositionIndex は node 以下のすべてのキーと要素の位置を索引にする
+func buildPositionIndex(file string, node *yaml.Node) PositionIndex {
+	idx := PositionIndex{}
+	idx[""] = Position{File: file, Line: node.Line, Column: node.Column}
+	indexNode(idx, file, node, "")
+	return idx
+}
+
+func indexNode(idx PositionIndex, file string, node *yaml.Node, path string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			p := joinPath(path, key.Value)
+			idx[p] = Position{File: file, Line: key.Line, Column: key.Column}
+			indexNode(idx, file, value, p)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			p := path + "[" + strconv.Itoa(i) + "]"
+			idx[p] = Position{File: file, Line: item.Line, Column: item.Column}
+			indexNode(idx, file, item, p)
+		}
+	}
+}
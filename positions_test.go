@@ -0,0 +1,132 @@
+package apispec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadDocument_Positions(t *testing.T) {
+	t.Parallel()
+	input := `app_name: myapp
+build:
+  image: myapp:latest
+releases:
+  - name: migrate
+    resources:
+      cpu: 500m
+      memory: 256Mi
+    action:
+      command: [bin/migrate]
+service:
+  name: web
+  command: [npm, start]
+`
+	doc, err := LoadDocument(strings.NewReader(input), FormatYAML)
+	if err != nil {
+		t.Fatalf("LoadDocument() error = %v", err)
+	}
+	tests := []struct {
+		path string
+		want Position
+	}{
+		{path: "app_name", want: Position{Line: 1, Column: 1}},
+		{path: "releases[0]", want: Position{Line: 5, Column: 5}},
+		{path: "releases[0].resources.cpu", want: Position{Line: 7, Column: 7}},
+		{path: "service.command[1]", want: Position{Line: 13, Column: 18}},
+	}
+	for _, tt := range tests {
+		got, ok := doc.Positions.Lookup(tt.path)
+		if !ok || got != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v, want %v", tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestLoadFile_ValidationErrorPositions(t *testing.T) {
+	t.Parallel()
+	input := `{
+  "app_name": "myapp",
+  "build": {"image": "myapp:latest"},
+  "releases": [
+    {
+      "name": "migrate",
+      "resources": {"memory": "256Mi"},
+      "action": {"command": ["bin/migrate"]}
+    }
+  ],
+  "service": {"name": "web", "command": ["npm", "start"]}
+}`
+	_, err := Load(strings.NewReader(input), FormatJSON)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("Load() error = %v, want ValidationErrors", err)
+	}
+	if len(verrs) != 1 {
+		t.Fatalf("len(ValidationErrors) = %d, want 1: %v", len(verrs), verrs)
+	}
+	got := verrs[0]
+	if got.Path != "releases[0].resources.cpu" {
+		t.Errorf("Path = %q, want %q", got.Path, "releases[0].resources.cpu")
+	}
+	// cpu が存在しないため、親の resources の位置が報告される
+	if want := (Position{Line: 7, Column: 7}); got.Position != want {
+		t.Errorf("Position = %v, want %v", got.Position, want)
+	}
+}
+
+func TestPositionIndex_Lookup(t *testing.T) {
+	t.Parallel()
+	idx := PositionIndex{
+		"":             {Line: 1, Column: 1},
+		"service":      {Line: 2, Column: 1},
+		"service.http": {Line: 3, Column: 3},
+	}
+	tests := []struct {
+		name string
+		path string
+		want Position
+	}{
+		{
+			name: "存在するパスの位置を返す",
+			path: "service.http",
+			want: Position{Line: 3, Column: 3},
+		},
+		{
+			name: "存在しないパスは最も近い祖先の位置を返す",
+			path: "service.http[0].target_port",
+			want: Position{Line: 3, Column: 3},
+		},
+		{
+			name: "祖先が存在しない場合はルートの位置を返す",
+			path: "build.image",
+			want: Position{Line: 1, Column: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, _ := idx.Lookup(tt.path)
+			if got != tt.want {
+				t.Errorf("Lookup(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPosition_String(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pos  Position
+		want string
+	}{
+		{pos: Position{File: "app.yaml", Line: 3, Column: 5}, want: "app.yaml:3:5"},
+		{pos: Position{Line: 3, Column: 5}, want: "3:5"},
+		{pos: Position{File: "app.yaml"}, want: "app.yaml"},
+	}
+	for _, tt := range tests {
+		if got := tt.pos.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
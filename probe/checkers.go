@@ -0,0 +1,113 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	apispec "github.com/tacokumo/appconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// httpClient はHTTPヘルスチェックに使うクライアント
+// リダイレクトは追跡せず、3xx のステータスコードも成功とみなす
+var httpClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// newHTTPChecker は Path にGETリクエストを送り、2xx または 3xx のステータスコードを成功とみなす Checker を作成する
+func newHTTPChecker(h *apispec.HealthcheckConfig, target Target) (Checker, error) {
+	cfg := h.HTTP
+	path := cfg.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := "http://" + target.Addr(cfg.Port) + path
+	return CheckerFunc(func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range cfg.Headers {
+			req.Header.Set(k, v)
+		}
+		// net/http は Host ヘッダーを無視するため、リクエストのホスト名として設定する
+		if host := req.Header.Get("Host"); host != "" {
+			req.Host = host
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+		}
+		return nil
+	}), nil
+}
+
+// newProcessChecker は Command を実行し、終了コード0で終了した場合を成功とみなす Checker を作成する
+func newProcessChecker(h *apispec.HealthcheckConfig, target Target) (Checker, error) {
+	command := h.Process.Command
+	if len(command) == 0 {
+		return nil, errors.New("process healthcheck has no command")
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Dir = target.Dir
+		cmd.Env = target.Env
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return fmt.Errorf("%s: %w: %s", command[0], err, msg)
+			}
+			return fmt.Errorf("%s: %w", command[0], err)
+		}
+		return nil
+	}), nil
+}
+
+// newTCPChecker はポートへの接続に成功した場合を成功とみなす Checker を作成する
+func newTCPChecker(h *apispec.HealthcheckConfig, target Target) (Checker, error) {
+	addr := target.Addr(h.TCP.Port)
+	return CheckerFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}), nil
+}
+
+// newGRPCChecker は gRPC Health Checking Protocol で問い合わせ、`SERVING` の場合を成功とみなす Checker を作成する
+func newGRPCChecker(h *apispec.HealthcheckConfig, target Target) (Checker, error) {
+	addr := target.Addr(h.GRPC.Port)
+	service := h.GRPC.Service
+	return CheckerFunc(func(ctx context.Context) error {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if s := resp.GetStatus(); s != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("grpc health %s: status %s", addr, s)
+		}
+		return nil
+	}), nil
+}
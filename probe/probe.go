@@ -0,0 +1,258 @@
+// Package probe は apispec.HealthcheckConfig に宣言されたヘルスチェックをローカルで実行する
+//
+// ローカルの開発ツールや結合テストで、プラットフォームと同じヘルスチェックの設定を再利用するために使う
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// Checker は1回のヘルスチェックを行う
+type Checker interface {
+	// Check はヘルスチェックを1回行い、正常な場合は nil を返す
+	// ctx には HealthcheckConfig.Timeout の制限時間が設定されている
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc は関数を Checker として扱う
+type CheckerFunc func(ctx context.Context) error
+
+// Check は f(ctx) を返す
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Target はヘルスチェックの対象を表す
+type Target struct {
+	// Host は対象のホスト名
+	Host string
+	// Port は対象のポート
+	// ヘルスチェックの設定でポートが指定されている場合はそちらが優先される
+	Port int
+	// Dir はプロセスヘルスチェックのコマンドを実行するディレクトリ
+	// 空の場合はカレントディレクトリで実行する
+	Dir string
+	// Env はプロセスヘルスチェックのコマンドに渡す環境変数 (`KEY=value` 形式)
+	// nil の場合は現在のプロセスの環境変数を引き継ぐ
+	Env []string
+}
+
+// Addr はポートを対象とするヘルスチェックの接続先を返す
+// port が0でない場合は Target.Port の代わりに使う
+func (t Target) Addr(port int) string {
+	if port == 0 {
+		port = t.Port
+	}
+	return net.JoinHostPort(t.Host, strconv.Itoa(port))
+}
+
+// Factory は HealthcheckConfig と Target から Checker を作成する
+type Factory func(h *apispec.HealthcheckConfig, target Target) (Checker, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[apispec.HealthcheckKind]Factory{
+		apispec.HealthcheckHTTP:    newHTTPChecker,
+		apispec.HealthcheckProcess: newProcessChecker,
+		apispec.HealthcheckTCP:     newTCPChecker,
+		apispec.HealthcheckGRPC:    newGRPCChecker,
+	}
+)
+
+// Register は kind のヘルスチェックを実行する Factory を登録する
+// 既に登録されている場合は置き換える
+func Register(kind apispec.HealthcheckKind, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[kind] = f
+}
+
+// ErrUnsupportedKind はヘルスチェックの種類に対応する Factory が登録されていないことを表す
+var ErrUnsupportedKind = errors.New("unsupported healthcheck kind")
+
+// NewChecker は h の種類に対応する Checker を作成する
+func NewChecker(h *apispec.HealthcheckConfig, target Target) (Checker, error) {
+	kind := h.Kind()
+	factoriesMu.RLock()
+	f, ok := factories[kind]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKind, kind)
+	}
+	return f(h, target)
+}
+
+// Status はしきい値を適用したヘルスチェックの状態を表す
+type Status int
+
+const (
+	// StatusUnknown はしきい値に達する結果がまだ得られていないことを表す
+	StatusUnknown Status = iota
+	// StatusHealthy は SuccessThreshold 回連続で成功したことを表す
+	StatusHealthy
+	// StatusUnhealthy は FailureThreshold 回連続で失敗したことを表す
+	StatusUnhealthy
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusUnhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// Result は1回のヘルスチェックの結果を表す
+type Result struct {
+	// Time はヘルスチェックを開始した時刻
+	Time time.Time
+	// Duration はヘルスチェックにかかった時間
+	Duration time.Duration
+	// Err はヘルスチェックが失敗した理由
+	// 成功した場合は nil
+	Err error
+	// Status はこの結果までを踏まえた状態
+	Status Status
+	// Changed はこの結果によって Status が変化したかどうか
+	Changed bool
+	// Successes は連続した成功の回数
+	Successes int
+	// Failures は連続した失敗の回数
+	Failures int
+}
+
+// Prober は HealthcheckConfig のタイミングとしきい値に従ってヘルスチェックを繰り返し実行する
+type Prober struct {
+	checker Checker
+	timings apispec.HealthcheckTimings
+}
+
+// New は h のヘルスチェックを target に対して実行する Prober を作成する
+func New(h *apispec.HealthcheckConfig, target Target) (*Prober, error) {
+	c, err := NewChecker(h, target)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithChecker(c, h.Timings()), nil
+}
+
+// NewWithChecker は c を timings に従って実行する Prober を作成する
+func NewWithChecker(c Checker, timings apispec.HealthcheckTimings) *Prober {
+	return &Prober{checker: c, timings: timings}
+}
+
+// Check はヘルスチェックを1回行う
+// HealthcheckConfig.Timeout を超えた場合は失敗とみなす
+func (p *Prober) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timings.Timeout)
+	defer cancel()
+	return p.checker.Check(ctx)
+}
+
+// Run は InitialDelay だけ待ってから Interval ごとにヘルスチェックを行い、結果を送信するチャネルを返す
+// チャネルは ctx が終了すると閉じられる
+//
+// ヘルスチェックは受信側を待たずに Interval ごとに行われる
+// 受信側が読み出す前に次の結果が得られた場合は古い結果を破棄するため、チャネルには常に最新の結果のみが残る
+// その場合も Result.Changed は受信側が最後に受け取った結果から Status が変化したかどうかを表す
+func (p *Prober) Run(ctx context.Context) <-chan Result {
+	results := make(chan Result, 1)
+	go func() {
+		defer close(results)
+		if !sleep(ctx, p.timings.InitialDelay) {
+			return
+		}
+		var state Result
+		// sent はチャネルに最後に送信した結果の Status
+		// queued はその結果を送信する前に受信側が受け取っていた Status
+		var sent, queued Status
+		for {
+			start := time.Now()
+			err := p.Check(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			state = p.next(state, start, time.Since(start), err)
+			r := state
+			seen := sent
+			select {
+			case <-results:
+				// 読み出されていない結果を破棄する
+				seen = queued
+			default:
+			}
+			r.Changed = r.Status != seen
+			// 送信するのはこの goroutine だけであり、チャネルは空いているためブロックしない
+			results <- r
+			sent, queued = r.Status, seen
+			if !sleep(ctx, p.timings.Interval) {
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// next は前回までの状態 prev に新しい結果を反映した Result を返す
+func (p *Prober) next(prev Result, start time.Time, d time.Duration, err error) Result {
+	r := Result{Time: start, Duration: d, Err: err, Status: prev.Status}
+	if err == nil {
+		r.Successes = prev.Successes + 1
+		if r.Successes >= p.timings.SuccessThreshold {
+			r.Status = StatusHealthy
+		}
+	} else {
+		r.Failures = prev.Failures + 1
+		if r.Failures >= p.timings.FailureThreshold {
+			r.Status = StatusUnhealthy
+		}
+	}
+	r.Changed = r.Status != prev.Status
+	return r
+}
+
+// ErrUnhealthy はヘルスチェックが FailureThreshold 回連続で失敗したことを表す
+var ErrUnhealthy = errors.New("healthcheck failed")
+
+// WaitHealthy は状態が StatusHealthy になるまでヘルスチェックを繰り返す
+// StatusUnhealthy になった場合は最後の失敗の理由を含む ErrUnhealthy を返す
+func (p *Prober) WaitHealthy(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for r := range p.Run(ctx) {
+		switch r.Status {
+		case StatusHealthy:
+			return nil
+		case StatusUnhealthy:
+			return fmt.Errorf("%w after %d attempts: %w", ErrUnhealthy, r.Failures, r.Err)
+		}
+	}
+	return ctx.Err()
+}
+
+// sleep は d だけ待つ
+// 待っている間に ctx が終了した場合は false を返す
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,301 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apispec "github.com/tacokumo/appconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// listenerTarget は l の待ち受けアドレスを Target に変換する
+func listenerTarget(t *testing.T, l net.Addr) Target {
+	t.Helper()
+	host, port, err := net.SplitHostPort(l.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Target{Host: host, Port: p}
+}
+
+func TestChecker_HTTP(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/healthz" && r.Header.Get("X-Probe") == "local":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/moved":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	target := listenerTarget(t, srv.Listener.Addr())
+
+	tests := []struct {
+		name    string
+		config  apispec.HealthcheckHTTPConfig
+		wantErr bool
+	}{
+		{
+			name:   "2xxを返す場合、成功する",
+			config: apispec.HealthcheckHTTPConfig{Path: "/healthz", Headers: map[string]string{"X-Probe": "local"}},
+		},
+		{
+			name:   "3xxを返す場合、リダイレクトを追跡せずに成功する",
+			config: apispec.HealthcheckHTTPConfig{Path: "/moved"},
+		},
+		{
+			name:    "5xxを返す場合、失敗する",
+			config:  apispec.HealthcheckHTTPConfig{Path: "/healthz"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := NewChecker(&apispec.HealthcheckConfig{HTTP: &tt.config}, target)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			if err := c.Check(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChecker_Process(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		command []string
+		wantErr bool
+	}{
+		{name: "終了コードが0の場合、成功する", command: []string{"sh", "-c", "exit 0"}},
+		{name: "終了コードが0以外の場合、失敗する", command: []string{"sh", "-c", "echo not ready; exit 1"}, wantErr: true},
+		{name: "コマンドが存在しない場合、失敗する", command: []string{"./no-such-command"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := &apispec.HealthcheckConfig{Process: &apispec.HealthcheckProcessConfig{Command: tt.command}}
+			c, err := NewChecker(h, Target{})
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			if err := c.Check(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChecker_TCP(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := listenerTarget(t, l.Addr())
+	h := &apispec.HealthcheckConfig{TCP: &apispec.HealthcheckTCPConfig{}}
+
+	c, err := NewChecker(h, target)
+	if err != nil {
+		t.Fatalf("NewChecker() error = %v", err)
+	}
+	if err := c.Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v, want nil", err)
+	}
+	l.Close()
+	if err := c.Check(context.Background()); err == nil {
+		t.Error("Check() error = nil after the listener is closed, want error")
+	}
+}
+
+func TestChecker_GRPC(t *testing.T) {
+	t.Parallel()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("myapp.v1.Greeter", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("myapp.v1.Admin", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	target := listenerTarget(t, l.Addr())
+
+	tests := []struct {
+		name    string
+		service string
+		wantErr bool
+	}{
+		{name: "SERVINGの場合、成功する", service: "myapp.v1.Greeter"},
+		{name: "サービス名を省略した場合、サーバー全体の状態を問い合わせる", service: ""},
+		{name: "NOT_SERVINGの場合、失敗する", service: "myapp.v1.Admin", wantErr: true},
+		{name: "未知のサービスの場合、失敗する", service: "myapp.v1.Unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := &apispec.HealthcheckConfig{GRPC: &apispec.HealthcheckGRPCConfig{Service: tt.service}}
+			c, err := NewChecker(h, target)
+			if err != nil {
+				t.Fatalf("NewChecker() error = %v", err)
+			}
+			if err := c.Check(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewChecker_Unsupported(t *testing.T) {
+	t.Parallel()
+	if _, err := NewChecker(&apispec.HealthcheckConfig{}, Target{}); !errors.Is(err, ErrUnsupportedKind) {
+		t.Errorf("NewChecker() error = %v, want ErrUnsupportedKind", err)
+	}
+}
+
+// sequence は results を順に返し、使い切った後は最後の結果を返し続ける Checker を作成する
+func sequence(results ...error) Checker {
+	i := 0
+	return CheckerFunc(func(context.Context) error {
+		err := results[min(i, len(results)-1)]
+		i++
+		return err
+	})
+}
+
+func TestProber_Run(t *testing.T) {
+	t.Parallel()
+	errDown := errors.New("down")
+	timings := apispec.HealthcheckTimings{
+		Interval:         time.Millisecond,
+		Timeout:          time.Second,
+		SuccessThreshold: 2,
+		FailureThreshold: 2,
+	}
+	// 結果が破棄されないよう、受信してから次のヘルスチェックを行う
+	step := make(chan struct{})
+	checker := sequence(nil, errDown, nil, nil, errDown, errDown, nil)
+	p := NewWithChecker(CheckerFunc(func(ctx context.Context) error {
+		select {
+		case <-step:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return checker.Check(ctx)
+	}), timings)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := p.Run(ctx)
+	want := []struct {
+		status  Status
+		changed bool
+	}{
+		{StatusUnknown, false},
+		{StatusUnknown, false},
+		{StatusUnknown, false},
+		{StatusHealthy, true},
+		{StatusHealthy, false},
+		{StatusUnhealthy, true},
+		{StatusUnhealthy, false},
+	}
+	for i, w := range want {
+		step <- struct{}{}
+		r := <-results
+		if r.Status != w.status || r.Changed != w.changed {
+			t.Errorf("result[%d] = {Status: %v, Changed: %v}, want {Status: %v, Changed: %v}", i, r.Status, r.Changed, w.status, w.changed)
+		}
+	}
+	cancel()
+	for range results {
+	}
+}
+
+func TestProber_Run_SlowReceiver(t *testing.T) {
+	t.Parallel()
+	var checks atomic.Int32
+	p := NewWithChecker(CheckerFunc(func(context.Context) error {
+		checks.Add(1)
+		return errors.New("down")
+	}), apispec.HealthcheckTimings{Interval: time.Millisecond, Timeout: time.Second, SuccessThreshold: 1, FailureThreshold: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := p.Run(ctx)
+
+	// 受信しなくてもヘルスチェックは Interval ごとに続く
+	deadline := time.Now().Add(5 * time.Second)
+	for checks.Load() < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d checks ran while the results were not received", checks.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// 破棄された結果の状態の変化は最新の結果の Changed に引き継がれる
+	r := <-results
+	if r.Status != StatusUnhealthy || !r.Changed || r.Failures < 5 {
+		t.Errorf("result = {Status: %v, Changed: %v, Failures: %d}, want the latest unhealthy result marked as changed", r.Status, r.Changed, r.Failures)
+	}
+	cancel()
+	for range results {
+	}
+}
+
+func TestProber_Timeout(t *testing.T) {
+	t.Parallel()
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	p := NewWithChecker(slow, apispec.HealthcheckTimings{Timeout: 10 * time.Millisecond})
+	if err := p.Check(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Check() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestProber_WaitHealthy(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	h := &apispec.HealthcheckConfig{
+		HTTP:     &apispec.HealthcheckHTTPConfig{Path: "/"},
+		Interval: apispec.MustParseDuration("1ms"),
+	}
+
+	p, err := New(h, listenerTarget(t, srv.Listener.Addr()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := p.WaitHealthy(context.Background()); err != nil {
+		t.Errorf("WaitHealthy() error = %v, want nil", err)
+	}
+
+	srv.Close()
+	if err := p.WaitHealthy(context.Background()); !errors.Is(err, ErrUnhealthy) {
+		t.Errorf("WaitHealthy() error = %v, want ErrUnhealthy", err)
+	}
+}
@@ -0,0 +1,317 @@
+package apispec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Quantity はKubernetes形式のリソース量 (例: `500m`, `2`, `256Mi`, `1G`) を表す
+// 内部ではミリ単位の整数として保持するため、1/1000 未満の端数は切り上げられる
+// 同じ理由で表せる値は約9.2P (8Pi より少し大きい値) までに限られ、接尾辞 E と Ei は使えない
+//
+// 設定ファイルから読み込んだ値が不正な場合はデコード時にはエラーにならず、
+// `quantity` ルールのバリデーションエラーとして報告される
+type Quantity struct {
+	milli  int64
+	format quantityFormat
+
+	// raw は解析に失敗した設定ファイル上の値
+	raw string
+}
+
+// quantityFormat は Quantity を文字列に変換する際の単位系を表す
+type quantityFormat int
+
+const (
+	// quantityUnset は値が設定されていないことを表す
+	quantityUnset quantityFormat = iota
+	// quantityDecimal は10進数の接尾辞 (k, M, G, ...) を使う単位系
+	quantityDecimal
+	// quantityBinary は2進数の接尾辞 (Ki, Mi, Gi, ...) を使う単位系
+	quantityBinary
+)
+
+// quantityNumber と quantitySuffixes はリソース量の数値と接尾辞の形式を表す
+const (
+	quantityNumber   = `(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)`
+	quantitySuffixes = `(m|k|M|G|T|P|Ki|Mi|Gi|Ti|Pi)?`
+)
+
+// quantityPattern は ParseQuantity が受け付けるリソース量の形式を表す
+var quantityPattern = regexp.MustCompile(`^([+-]?` + quantityNumber + `)` + quantitySuffixes + `$`)
+
+// unsignedQuantityPattern は設定ファイルに記述できるリソース量の形式を表す
+// 負の値は許可しないため、符号は記述できない
+var unsignedQuantityPattern = regexp.MustCompile(`^(` + quantityNumber + `)` + quantitySuffixes + `$`)
+
+// quantitySuffix は接尾辞と倍率の対応を表す
+type quantitySuffix struct {
+	suffix     string
+	multiplier int64
+}
+
+var (
+	decimalSuffixes = []quantitySuffix{
+		{"P", 1e15}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"k", 1e3},
+	}
+	binarySuffixes = []quantitySuffix{
+		{"Pi", 1 << 50}, {"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10},
+	}
+)
+
+// ErrInvalidQuantity はリソース量の形式が不正であることを表す
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+// ParseQuantity は s をリソース量として解析する
+func ParseQuantity(s string) (Quantity, error) {
+	m := quantityPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Quantity{}, fmt.Errorf("%w %q: must be a number with an optional unit suffix such as 500m or 256Mi", ErrInvalidQuantity, s)
+	}
+	number, suffix := m[1], m[2]
+
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return Quantity{}, fmt.Errorf("%w %q", ErrInvalidQuantity, s)
+	}
+	format := quantityDecimal
+	multiplier := big.NewRat(1000, 1)
+	switch suffix {
+	case "":
+	case "m":
+		multiplier = big.NewRat(1, 1)
+	default:
+		for _, sfx := range binarySuffixes {
+			if sfx.suffix == suffix {
+				format = quantityBinary
+				multiplier.Mul(multiplier, new(big.Rat).SetInt64(sfx.multiplier))
+			}
+		}
+		for _, sfx := range decimalSuffixes {
+			if sfx.suffix == suffix {
+				multiplier.Mul(multiplier, new(big.Rat).SetInt64(sfx.multiplier))
+			}
+		}
+	}
+	value.Mul(value, multiplier)
+
+	// ミリ単位未満の端数は切り上げる
+	milli := new(big.Int).Quo(value.Num(), value.Denom())
+	if new(big.Rat).SetInt(milli).Cmp(value) < 0 {
+		milli.Add(milli, big.NewInt(1))
+	}
+	if !milli.IsInt64() {
+		return Quantity{}, fmt.Errorf("%w %q: out of range", ErrInvalidQuantity, s)
+	}
+	return Quantity{milli: milli.Int64(), format: format}, nil
+}
+
+// MustParseQuantity は ParseQuantity と同様だが、解析に失敗した場合は panic する
+func MustParseQuantity(s string) Quantity {
+	q, err := ParseQuantity(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// NewMilliQuantity はミリ単位の値から10進数の単位系の Quantity を作成する
+func NewMilliQuantity(milli int64) Quantity {
+	return Quantity{milli: milli, format: quantityDecimal}
+}
+
+// NewBinaryQuantity はバイト数などの値から2進数の単位系の Quantity を作成する
+// value がミリ単位で表せない大きさの場合は panic する
+func NewBinaryQuantity(value int64) Quantity {
+	if value > math.MaxInt64/1000 || value < math.MinInt64/1000 {
+		panic(fmt.Sprintf("apispec: quantity %d is out of range", value))
+	}
+	return Quantity{milli: value * 1000, format: quantityBinary}
+}
+
+// IsSet は値が設定されているかどうかを返す
+func (q Quantity) IsSet() bool {
+	return q.format != quantityUnset || q.raw != ""
+}
+
+// IsZero は値が設定されていないかどうかを返す
+// YAMLの omitempty は IsZero によって省略するかどうかを判定するため、明示的に指定された `0` は省略されない
+func (q Quantity) IsZero() bool {
+	return !q.IsSet()
+}
+
+// MilliValue はミリ単位の値を返す
+func (q Quantity) MilliValue() int64 {
+	return q.milli
+}
+
+// Value は値を整数に切り上げて返す
+func (q Quantity) Value() int64 {
+	v := q.milli / 1000
+	if q.milli%1000 > 0 {
+		v++
+	}
+	return v
+}
+
+// Cmp は q と other を比較し、q が小さい場合は -1、等しい場合は 0、大きい場合は 1 を返す
+func (q Quantity) Cmp(other Quantity) int {
+	switch {
+	case q.milli < other.milli:
+		return -1
+	case q.milli > other.milli:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Add は q と other の和を返す
+// 単位系は q のものを引き継ぐ
+// 結果がミリ単位で表せない大きさの場合は panic する
+func (q Quantity) Add(other Quantity) Quantity {
+	sum := q.milli + other.milli
+	if (other.milli > 0 && sum < q.milli) || (other.milli < 0 && sum > q.milli) {
+		panic(fmt.Sprintf("apispec: %s + %s is out of range", q, other))
+	}
+	return Quantity{milli: sum, format: q.resultFormat(other)}
+}
+
+// Sub は q から other を引いた差を返す
+// 単位系は q のものを引き継ぐ
+// 結果がミリ単位で表せない大きさの場合は panic する
+func (q Quantity) Sub(other Quantity) Quantity {
+	diff := q.milli - other.milli
+	if (other.milli > 0 && diff > q.milli) || (other.milli < 0 && diff < q.milli) {
+		panic(fmt.Sprintf("apispec: %s - %s is out of range", q, other))
+	}
+	return Quantity{milli: diff, format: q.resultFormat(other)}
+}
+
+func (q Quantity) resultFormat(other Quantity) quantityFormat {
+	if q.format != quantityUnset {
+		return q.format
+	}
+	if other.format != quantityUnset {
+		return other.format
+	}
+	return quantityDecimal
+}
+
+// String は正規化された表現 (例: `1000m` は `1`、`1024Mi` は `1Gi`) を返す
+// 値が設定されていない場合は空文字列を返す
+func (q Quantity) String() string {
+	if q.raw != "" {
+		return q.raw
+	}
+	if q.format == quantityUnset {
+		return ""
+	}
+	if q.milli%1000 != 0 {
+		return strconv.FormatInt(q.milli, 10) + "m"
+	}
+	v := q.milli / 1000
+	if v == 0 {
+		return "0"
+	}
+	suffixes := decimalSuffixes
+	if q.format == quantityBinary {
+		suffixes = binarySuffixes
+	}
+	for _, sfx := range suffixes {
+		if v%sfx.multiplier == 0 {
+			return strconv.FormatInt(v/sfx.multiplier, 10) + sfx.suffix
+		}
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// MarshalJSON は正規化された表現を文字列として出力する
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.String())
+}
+
+// UnmarshalJSON は文字列または数値をリソース量として読み込む
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%w: must be a string or a number", ErrInvalidQuantity)
+		}
+		s = n.String()
+	}
+	q.set(s)
+	return nil
+}
+
+// MarshalYAML は正規化された表現を文字列として出力する
+func (q Quantity) MarshalYAML() (any, error) {
+	return q.String(), nil
+}
+
+// UnmarshalYAML はスカラー値をリソース量として読み込む
+func (q *Quantity) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: %w: must be a scalar value", node.Line, ErrInvalidQuantity)
+	}
+	q.set(node.Value)
+	return nil
+}
+
+// set は s を解析して q に設定する
+// 解析に失敗した場合、または符号が付いている場合は元の値を保持し、バリデーションで報告する
+func (q *Quantity) set(s string) {
+	if s == "" {
+		*q = Quantity{}
+		return
+	}
+	parsed, err := ParseQuantity(s)
+	if err != nil || !unsignedQuantityPattern.MatchString(s) {
+		*q = Quantity{raw: s}
+		return
+	}
+	*q = parsed
+}
+
+func (Quantity) jsonSchema() map[string]any {
+	return map[string]any{
+		"type":    []string{"string", "number"},
+		"pattern": unsignedQuantityPattern.String(),
+		"minimum": 0,
+	}
+}
+
+// quantityValue は Quantity をバリデーション用の文字列に変換する
+// 値が設定されていない場合は空文字列になるため、`required` ルールで検出できる
+func quantityValue(v reflect.Value) any {
+	q, ok := v.Interface().(Quantity)
+	if !ok {
+		return nil
+	}
+	return q.String()
+}
+
+// validateQuantity は `quantity` ルールを実装する
+// 値がスキーマと同じ unsignedQuantityPattern の形式で、リソース量として解析できることを検証する
+// 符号付きの値は set で元の値が保持されるため、ここで拒否される
+func validateQuantity(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "" {
+		return true
+	}
+	if !unsignedQuantityPattern.MatchString(s) {
+		return false
+	}
+	_, err := ParseQuantity(s)
+	return err == nil
+}
@@ -0,0 +1,271 @@
+package apispec
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseQuantity(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input     string
+		wantMilli int64
+		wantStr   string
+		wantErr   bool
+	}{
+		{input: "500m", wantMilli: 500, wantStr: "500m"},
+		{input: "2", wantMilli: 2000, wantStr: "2"},
+		{input: "0.5", wantMilli: 500, wantStr: "500m"},
+		{input: "1000m", wantMilli: 1000, wantStr: "1"},
+		{input: "0", wantMilli: 0, wantStr: "0"},
+		{input: "256Mi", wantMilli: 256 << 20 * 1000, wantStr: "256Mi"},
+		{input: "1024Mi", wantMilli: 1 << 30 * 1000, wantStr: "1Gi"},
+		{input: "1.5Gi", wantMilli: 1536 << 20 * 1000, wantStr: "1536Mi"},
+		{input: "1G", wantMilli: 1e9 * 1000, wantStr: "1G"},
+		{input: "1500M", wantMilli: 1500e6 * 1000, wantStr: "1500M"},
+		{input: "2000k", wantMilli: 2e6 * 1000, wantStr: "2M"},
+		{input: "0.1m", wantMilli: 1, wantStr: "1m"},
+		{input: "lots", wantErr: true},
+		{input: "256MB", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "1 Gi", wantErr: true},
+		{input: "1Pi", wantMilli: 1 << 50 * 1000, wantStr: "1Pi"},
+		{input: "1E", wantErr: true},
+		{input: "1Ei", wantErr: true},
+		{input: "10000P", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			q, err := ParseQuantity(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuantity(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidQuantity) {
+					t.Errorf("ParseQuantity(%q) error = %v, want ErrInvalidQuantity", tt.input, err)
+				}
+				return
+			}
+			if q.MilliValue() != tt.wantMilli {
+				t.Errorf("MilliValue() = %d, want %d", q.MilliValue(), tt.wantMilli)
+			}
+			if q.String() != tt.wantStr {
+				t.Errorf("String() = %q, want %q", q.String(), tt.wantStr)
+			}
+		})
+	}
+}
+
+func TestQuantity_Arithmetic(t *testing.T) {
+	t.Parallel()
+	a := MustParseQuantity("500m")
+	b := MustParseQuantity("1500m")
+	if got := a.Add(b).String(); got != "2" {
+		t.Errorf("Add() = %q, want %q", got, "2")
+	}
+	if got := b.Sub(a).String(); got != "1" {
+		t.Errorf("Sub() = %q, want %q", got, "1")
+	}
+	if got := a.Cmp(b); got != -1 {
+		t.Errorf("Cmp() = %d, want -1", got)
+	}
+	if got := b.Cmp(a); got != 1 {
+		t.Errorf("Cmp() = %d, want 1", got)
+	}
+	if got := MustParseQuantity("1Gi").Cmp(MustParseQuantity("1024Mi")); got != 0 {
+		t.Errorf("Cmp() = %d, want 0", got)
+	}
+	if got := MustParseQuantity("1Gi").Add(MustParseQuantity("512Mi")).String(); got != "1536Mi" {
+		t.Errorf("Add() = %q, want %q", got, "1536Mi")
+	}
+	if got := MustParseQuantity("1500m").Value(); got != 2 {
+		t.Errorf("Value() = %d, want 2", got)
+	}
+}
+
+func TestQuantity_Overflow(t *testing.T) {
+	t.Parallel()
+	max := MustParseQuantity("9223372036854775807m")
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{name: "Add", f: func() { max.Add(MustParseQuantity("1m")) }},
+		{name: "Sub", f: func() { max.Sub(MustParseQuantity("1m")).Sub(max).Sub(max).Sub(max) }},
+		{name: "NewBinaryQuantity", f: func() { NewBinaryQuantity(1 << 60) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic on overflow", tt.name)
+				}
+			}()
+			tt.f()
+		})
+	}
+}
+
+func TestQuantity_IsZero(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "明示的な0は省略されない", input: "{cpu: 0}", want: "cpu: \"0\"\n"},
+		{name: "解析できない値は省略されない", input: "{cpu: lots}", want: "cpu: lots\n"},
+		{name: "設定されていない値は省略される", input: "{}", want: "{}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var r MachineOverridesConfig
+			if err := yaml.Unmarshal([]byte(tt.input), &r); err != nil {
+				t.Fatal(err)
+			}
+			got, err := yaml.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("yaml.Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantity_Marshal(t *testing.T) {
+	t.Parallel()
+	r := ResourceConfig{CPU: MustParseQuantity("1000m"), Memory: MustParseQuantity("1024Mi")}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"cpu":"1","memory":"1Gi"}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+	var fromJSON ResourceConfig
+	if err := json.Unmarshal([]byte(`{"cpu":2,"memory":"1Gi"}`), &fromJSON); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if fromJSON.CPU.String() != "2" || fromJSON.Memory.Cmp(r.Memory) != 0 {
+		t.Errorf("json.Unmarshal() = %+v", fromJSON)
+	}
+
+	y, err := yaml.Marshal(r)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	if want := "cpu: \"1\"\nmemory: 1Gi\n"; string(y) != want {
+		t.Errorf("yaml.Marshal() = %q, want %q", y, want)
+	}
+	var fromYAML ResourceConfig
+	if err := yaml.Unmarshal(y, &fromYAML); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if fromYAML.CPU.Cmp(r.CPU) != 0 || fromYAML.Memory.Cmp(r.Memory) != 0 {
+		t.Errorf("yaml.Unmarshal() = %+v, want %+v", fromYAML, r)
+	}
+}
+
+func TestQuantity_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		input    string
+		wantPath string
+	}{
+		{
+			name:  "正しいリソース量の場合、エラーにならない",
+			input: "{cpu: 500m, memory: 256Mi}",
+		},
+		{
+			name:     "単位が不正な場合、エラーになる",
+			input:    "{cpu: 500m, memory: 256MB}",
+			wantPath: "memory",
+		},
+		{
+			name:     "数値でない場合、エラーになる",
+			input:    "{cpu: lots, memory: 256Mi}",
+			wantPath: "cpu",
+		},
+		{
+			name:     "負の値の場合、エラーになる",
+			input:    "{cpu: -1, memory: 256Mi}",
+			wantPath: "cpu",
+		},
+		{
+			name:     "負の値の文字列の場合、エラーになる",
+			input:    `{cpu: "-1", memory: 256Mi}`,
+			wantPath: "cpu",
+		},
+		{
+			name:     "符号が付いている場合、エラーになる",
+			input:    "{cpu: +1, memory: 256Mi}",
+			wantPath: "cpu",
+		},
+		{
+			name:     "表せる範囲を超える場合、エラーになる",
+			input:    "{cpu: 500m, memory: 1Ei}",
+			wantPath: "memory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var r ResourceConfig
+			if err := yaml.NewDecoder(strings.NewReader(tt.input)).Decode(&r); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			err := validateStruct(r)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Errorf("validateStruct() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("validateStruct() error = %v, want one ValidationError", err)
+			}
+			if errs[0].Path != tt.wantPath || errs[0].Rule != "quantity" {
+				t.Errorf("ValidationError = %+v, want path %q and rule quantity", errs[0], tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestQuantity_PatternMatchesValidation(t *testing.T) {
+	t.Parallel()
+	schema := compileSchema(t)
+	valid, err := os.ReadFile("testdata/valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// スキーマとバリデーションは同じ値を受け付ける
+	for _, s := range []string{`"500m"`, `"0"`, `"1.5Gi"`, `".5"`, `"-1"`, `"-0"`, `"+1"`, `"-500m"`, `"256MB"`, `1`, `0`, `-1`} {
+		var r ResourceConfig
+		if err := json.Unmarshal([]byte(`{"cpu":`+s+`,"memory":"256Mi"}`), &r); err != nil {
+			t.Fatal(err)
+		}
+		validated := validateStruct(r) == nil
+		input := strings.Replace(string(valid), "cpu: 500m", "cpu: "+s, 1)
+		matched := schema.Validate(yamlToJSONValue(t, input)) == nil
+		if validated != matched {
+			t.Errorf("%s: validation = %v, schema = %v", s, validated, matched)
+		}
+	}
+}
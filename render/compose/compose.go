@@ -0,0 +1,331 @@
+// Package compose は AppConfig からローカル開発用の compose.yaml を生成する
+//
+// サービスとワーカーは常駐するサービスに、リリースは宣言された順に1回だけ実行されるサービスに変換する
+// サービスとワーカーはすべてのリリースが成功した後に起動する
+// ジョブは `jobs` プロファイルのサービスに変換し、`docker compose run` で手動で実行する
+package compose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apispec "github.com/tacokumo/appconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// JobsProfile はジョブのサービスに付けるプロファイル
+const JobsProfile = "jobs"
+
+// ErrDuplicateService はリリースのサービスの名前 (`release-<name>`) がサービス、ワーカー、ジョブの名前と重複していることを表す
+var ErrDuplicateService = errors.New("duplicate compose service name")
+
+// Options は compose.yaml の生成方法を表す
+type Options struct {
+	// Stage はステージの名前
+	// 指定した場合は AppConfig.ForStage で上書き設定を適用し、プロジェクトの名前にステージの名前を含める
+	Stage string
+	// Image はコンテナイメージ
+	// 指定した場合は build の設定に関わらずイメージをビルドせずに使用する
+	Image string
+}
+
+// Render は cfg の compose.yaml のプロジェクトを生成する
+// cfg はバリデーション済みでなければならない
+//
+// 環境変数のシークレットへの参照は、同じ名前の変数の展開 (`${NAME}`) に変換する
+// シークレットの値は compose.yaml に含めず、シェルの環境変数や `.env` ファイルから渡す
+// フィールドへの参照は参照先の値に変換する
+//
+// ヘルスチェックはコンテナ内で実行されるため、HTTP は `curl`、TCP は `nc`、gRPC は `grpc_health_probe` が
+// イメージに含まれている必要がある
+// スケーリング設定はホストのポートと衝突するため無視する
+func Render(cfg *apispec.AppConfig, opts Options) (*Project, error) {
+	name := cfg.AppName
+	if opts.Stage != "" {
+		resolved, err := cfg.ForStage(opts.Stage)
+		if err != nil {
+			return nil, err
+		}
+		cfg = &resolved.Config
+		name += "-" + opts.Stage
+	}
+	r := &renderer{cfg: cfg, opts: opts}
+	return r.render(projectName(name))
+}
+
+// Marshal は p を compose.yaml の内容に変換する
+func Marshal(p *Project) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type renderer struct {
+	cfg  *apispec.AppConfig
+	opts Options
+}
+
+func (r *renderer) render(name string) (*Project, error) {
+	p := &Project{Name: name, Services: map[string]*Service{}}
+
+	// リリースは前のリリースが成功した後に順に実行する
+	var lastRelease string
+	for _, rel := range r.cfg.Releases {
+		s, err := r.service(rel.Action.Command, rel.Env)
+		if err != nil {
+			return nil, fmt.Errorf("release %q: %w", rel.Name, err)
+		}
+		setResources(s, rel.Resources)
+		s.Restart = "no"
+		s.DependsOn = dependsOnRelease(lastRelease)
+		lastRelease = releaseServiceName(rel)
+		if err := p.addService(lastRelease, s); err != nil {
+			return nil, fmt.Errorf("release %q: %w", rel.Name, err)
+		}
+	}
+
+	svc := &r.cfg.Service
+	s, err := r.service(svc.Command, svc.Env)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", svc.Name, err)
+	}
+	for _, h := range svc.HTTP {
+		port := strconv.Itoa(h.TargetPort)
+		s.Ports = append(s.Ports, PortMapping(port+":"+port))
+	}
+	if m := svc.MachineConfig; m != nil {
+		setResources(s, apispec.ResourceConfig{CPU: m.CPU, Memory: m.Memory})
+	}
+	s.Healthcheck = r.healthcheck()
+	s.DependsOn = dependsOnRelease(lastRelease)
+	if err := p.addService(svc.Name, s); err != nil {
+		return nil, fmt.Errorf("service %q: %w", svc.Name, err)
+	}
+
+	for _, w := range r.cfg.Workers {
+		s, err := r.service(w.Command, w.Env)
+		if err != nil {
+			return nil, fmt.Errorf("worker %q: %w", w.Name, err)
+		}
+		if m := w.MachineConfig; m != nil {
+			setResources(s, apispec.ResourceConfig{CPU: m.CPU, Memory: m.Memory})
+		}
+		s.DependsOn = dependsOnRelease(lastRelease)
+		if err := p.addService(w.Name, s); err != nil {
+			return nil, fmt.Errorf("worker %q: %w", w.Name, err)
+		}
+	}
+
+	for _, j := range r.cfg.Jobs {
+		s, err := r.service(j.Command, j.Env)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", j.Name, err)
+		}
+		setResources(s, j.Resources)
+		s.Restart = "no"
+		s.Profiles = []string{JobsProfile}
+		if err := p.addService(j.Name, s); err != nil {
+			return nil, fmt.Errorf("job %q: %w", j.Name, err)
+		}
+	}
+	return p, nil
+}
+
+// addService は name のサービスを p に追加する
+// サービス、ワーカー、ジョブの名前の重複はバリデーションで検出されるが、
+// リリースのサービスの名前 (`release-<name>`) とは重複しうるため、その場合は ErrDuplicateService を返す
+func (p *Project) addService(name string, s *Service) error {
+	if _, ok := p.Services[name]; ok {
+		return fmt.Errorf("%w %q", ErrDuplicateService, name)
+	}
+	p.Services[name] = s
+	return nil
+}
+
+// service は command を実行するサービスを返す
+// service.http が宣言され、PORT が指定されていない場合は runner と同様に最初の target_port を PORT として渡す
+func (r *renderer) service(command []string, env map[string]apispec.EnvValue) (*Service, error) {
+	s := &Service{Command: slices.Clone(command)}
+	if r.opts.Image != "" {
+		s.Image = r.opts.Image
+	} else if b := r.cfg.Build; b.Image != "" {
+		s.Image = b.Image
+	} else {
+		s.Build = build(b)
+	}
+	environment, err := r.environment(env)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := environment["PORT"]; !ok && len(r.cfg.Service.HTTP) > 0 {
+		if environment == nil {
+			environment = map[string]string{}
+		}
+		environment["PORT"] = strconv.Itoa(r.cfg.Service.HTTP[0].TargetPort)
+	}
+	s.Environment = environment
+	return s, nil
+}
+
+// environment は env を compose.yaml の環境変数に変換する
+// compose.yaml では `$` が変数の展開として扱われるため、値の `$` は `$$` にエスケープする
+func (r *renderer) environment(env map[string]apispec.EnvValue) (map[string]string, error) {
+	if len(env) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]apispec.EnvValue, len(env))
+	var secrets []string
+	for k, v := range env {
+		if v.Kind() == apispec.EnvSecret {
+			secrets = append(secrets, k)
+			continue
+		}
+		values[k] = v
+	}
+	// シークレットへの参照を除いているため、参照を解決するためのリゾルバは不要
+	resolved, err := r.cfg.ResolveEnv(context.Background(), values, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range resolved {
+		resolved[k] = escape(v)
+	}
+	for _, k := range secrets {
+		resolved[k] = "${" + k + "}"
+	}
+	return resolved, nil
+}
+
+// healthcheck はサービスの readiness のヘルスチェックを compose.yaml のヘルスチェックに変換する
+func (r *renderer) healthcheck() *Healthcheck {
+	svc := &r.cfg.Service
+	h := svc.Probe(apispec.ProbeReadiness)
+	if h == nil {
+		return nil
+	}
+	port := strconv.Itoa(svc.HealthcheckPort(h))
+	var test []string
+	switch h.Kind() {
+	case apispec.HealthcheckHTTP:
+		test = []string{"CMD", "curl", "-fsS", "-o", "/dev/null"}
+		for _, name := range slices.Sorted(maps.Keys(h.HTTP.Headers)) {
+			test = append(test, "-H", name+": "+h.HTTP.Headers[name])
+		}
+		test = append(test, "http://localhost:"+port+h.HTTP.Path)
+	case apispec.HealthcheckProcess:
+		test = append([]string{"CMD"}, h.Process.Command...)
+	case apispec.HealthcheckTCP:
+		test = []string{"CMD", "nc", "-z", "localhost", port}
+	case apispec.HealthcheckGRPC:
+		test = []string{"CMD", "grpc_health_probe", "-addr=localhost:" + port}
+		if h.GRPC.Service != "" {
+			test = append(test, "-service="+h.GRPC.Service)
+		}
+	}
+	t := h.Timings()
+	return &Healthcheck{
+		Test:        test,
+		Interval:    duration(t.Interval),
+		Timeout:     duration(t.Timeout),
+		Retries:     t.FailureThreshold,
+		StartPeriod: duration(t.InitialDelay),
+	}
+}
+
+// build は b を compose.yaml のビルド設定に変換する
+// ビルド引数の値の `$` は環境変数の値と同様にエスケープする
+// コンテキストを省略した場合は Dockerfile のあるディレクトリをコンテキストとし、Dockerfile はコンテキストからの相対パスにする
+func build(b apispec.BuildConfig) *Build {
+	dir := b.DockerContext
+	if dir == "" {
+		dir = filepath.Dir(b.Dockerfile)
+	}
+	dockerfile := b.Dockerfile
+	if rel, err := filepath.Rel(dir, b.Dockerfile); err == nil {
+		dockerfile = rel
+	}
+	if dockerfile == "Dockerfile" {
+		dockerfile = ""
+	}
+	var args map[string]string
+	if len(b.BuildArgs) > 0 {
+		args = make(map[string]string, len(b.BuildArgs))
+		for k, v := range b.BuildArgs {
+			args[k] = escape(v)
+		}
+	}
+	return &Build{Context: filepath.ToSlash(dir), Dockerfile: filepath.ToSlash(dockerfile), Args: args}
+}
+
+// escape は compose.yaml で変数の展開として扱われないよう、s の `$` を `$$` にエスケープする
+func escape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// setResources は c を s のCPUとメモリの上限に設定する
+func setResources(s *Service, c apispec.ResourceConfig) {
+	s.CPUs = strconv.FormatFloat(float64(c.CPU.MilliValue())/1000, 'f', -1, 64)
+	s.MemLimit = memory(c.Memory)
+}
+
+// memory は q を compose.yaml のバイト数の表記 (例: `256m`) に変換する
+func memory(q apispec.Quantity) string {
+	v := q.Value()
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}} {
+		if v >= unit.size && v%unit.size == 0 {
+			return strconv.FormatInt(v/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(v, 10) + "b"
+}
+
+// duration は d を compose.yaml の時間の表記に変換する
+// 0 の場合は省略する
+func duration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func dependsOnRelease(release string) map[string]Dependency {
+	if release == "" {
+		return nil
+	}
+	return map[string]Dependency{release: {Condition: ConditionCompletedSuccessfully}}
+}
+
+// releaseServiceName はリリースのサービスの名前を返す
+func releaseServiceName(rel apispec.ReleaseConfig) string {
+	return "release-" + rel.Name
+}
+
+// projectName は s を compose のプロジェクト名に使える文字列に変換する
+// 英小文字、数字、`-`、`_` 以外の文字は `-` に置き換える
+func projectName(s string) string {
+	b := []byte(strings.ToLower(s))
+	for i, c := range b {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			b[i] = '-'
+		}
+	}
+	return string(b)
+}
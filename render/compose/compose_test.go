@@ -0,0 +1,158 @@
+package compose
+
+import (
+	"errors"
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRender_Golden(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		file   string
+		opts   Options
+		golden string
+	}{
+		{
+			name:   "イメージを使用するサービスとリリースを生成する",
+			file:   "../../testdata/valid.yaml",
+			golden: "valid.golden.yaml",
+		},
+		{
+			name:   "ステージの上書き設定を適用し、シークレットを変数の展開に変換する",
+			file:   "../../testdata/stages.yaml",
+			opts:   Options{Stage: "staging"},
+			golden: "stages-staging.golden.yaml",
+		},
+		{
+			name:   "ワーカーを常駐するサービスに、ジョブをプロファイル付きのサービスに変換する",
+			file:   "../../testdata/processes.yaml",
+			golden: "processes.golden.yaml",
+		},
+		{
+			name:   "Dockerfileのビルド、リリースの順序、ヘルスチェックを変換する",
+			file:   "testdata/probes.yaml",
+			golden: "probes.golden.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := apispec.LoadFile(tt.file)
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			p, err := Render(cfg, tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			got, err := Marshal(p)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run `go test ./render/compose -update` to create it)", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Render() does not match %s (run `go test ./render/compose -update` to update it):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestRender_DuplicateService(t *testing.T) {
+	t.Parallel()
+	resources := apispec.ResourceConfig{CPU: apispec.MustParseQuantity("100m"), Memory: apispec.MustParseQuantity("64Mi")}
+	cfg := &apispec.AppConfig{
+		AppName:  "myapp",
+		Build:    apispec.BuildConfig{Image: "myapp:latest"},
+		Releases: []apispec.ReleaseConfig{{Name: "migrate", Resources: resources, Action: apispec.ReleaseActionConfig{Command: []string{"migrate"}}}},
+		Service:  apispec.ServiceConfig{Name: "web", Command: []string{"start"}},
+		Workers:  []apispec.WorkerConfig{{Name: "release-migrate", Command: []string{"work"}}},
+	}
+	if _, err := Render(cfg, Options{}); !errors.Is(err, ErrDuplicateService) {
+		t.Errorf("Render() error = %v, want ErrDuplicateService", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		build apispec.BuildConfig
+		want  Build
+	}{
+		{
+			name:  "コンテキストを省略した場合、Dockerfileのあるディレクトリをコンテキストにする",
+			build: apispec.BuildConfig{Dockerfile: "Dockerfile"},
+			want:  Build{Context: "."},
+		},
+		{
+			name:  "Dockerfileはコンテキストからの相対パスにする",
+			build: apispec.BuildConfig{Dockerfile: "docker/web.Dockerfile", DockerContext: "."},
+			want:  Build{Context: ".", Dockerfile: "docker/web.Dockerfile"},
+		},
+		{
+			name:  "サブディレクトリのDockerfileはそのディレクトリをコンテキストにする",
+			build: apispec.BuildConfig{Dockerfile: "services/web/Dockerfile"},
+			want:  Build{Context: "services/web"},
+		},
+		{
+			name:  "ビルド引数の値の$はエスケープする",
+			build: apispec.BuildConfig{Dockerfile: "Dockerfile", BuildArgs: map[string]string{"PS1": "$HOME> "}},
+			want:  Build{Context: ".", Args: map[string]string{"PS1": "$$HOME> "}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := build(tt.build)
+			if got.Context != tt.want.Context || got.Dockerfile != tt.want.Dockerfile {
+				t.Errorf("build() = {Context: %q, Dockerfile: %q}, want {Context: %q, Dockerfile: %q}", got.Context, got.Dockerfile, tt.want.Context, tt.want.Dockerfile)
+			}
+			if !maps.Equal(got.Args, tt.want.Args) {
+				t.Errorf("build().Args = %v, want %v", got.Args, tt.want.Args)
+			}
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		quantity string
+		want     string
+	}{
+		{quantity: "256Mi", want: "256m"},
+		{quantity: "2Gi", want: "2g"},
+		{quantity: "1536Ki", want: "1536k"},
+		{quantity: "1G", want: "1000000000b"},
+		{quantity: "100", want: "100b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.quantity, func(t *testing.T) {
+			t.Parallel()
+			if got := memory(apispec.MustParseQuantity(tt.quantity)); got != tt.want {
+				t.Errorf("memory(%s) = %q, want %q", tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
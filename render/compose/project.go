@@ -0,0 +1,65 @@
+package compose
+
+import "gopkg.in/yaml.v3"
+
+// このファイルの型は Compose Specification のうち、生成するファイルに必要なフィールドのみを表す
+
+// Project は compose.yaml 全体を表す
+type Project struct {
+	// Name はプロジェクトの名前
+	Name string `yaml:"name"`
+	// Services はサービスの名前からサービスへの対応
+	Services map[string]*Service `yaml:"services"`
+}
+
+// Service は compose.yaml のサービスを表す
+type Service struct {
+	Image       string                `yaml:"image,omitempty"`
+	Build       *Build                `yaml:"build,omitempty"`
+	Command     []string              `yaml:"command,omitempty"`
+	Ports       []PortMapping         `yaml:"ports,omitempty"`
+	Environment map[string]string     `yaml:"environment,omitempty"`
+	Healthcheck *Healthcheck          `yaml:"healthcheck,omitempty"`
+	DependsOn   map[string]Dependency `yaml:"depends_on,omitempty"`
+	Restart     string                `yaml:"restart,omitempty"`
+	Profiles    []string              `yaml:"profiles,omitempty"`
+	CPUs        string                `yaml:"cpus,omitempty"`
+	MemLimit    string                `yaml:"mem_limit,omitempty"`
+}
+
+// PortMapping はホストのポートとコンテナのポートの対応 (例: `8080:8080`) を表す
+type PortMapping string
+
+// MarshalYAML は常に引用符で囲んで出力する
+// YAML 1.1 のパーサーが `22:22` のような値を60進数として解釈しないようにする
+func (p PortMapping) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: string(p)}, nil
+}
+
+// Build はイメージのビルド設定を表す
+type Build struct {
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile,omitempty"`
+	Args       map[string]string `yaml:"args,omitempty"`
+}
+
+// Healthcheck はコンテナ内で実行するヘルスチェックを表す
+type Healthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+// Dependency はサービスの起動順序の依存関係を表す
+type Dependency struct {
+	Condition string `yaml:"condition"`
+}
+
+// 依存先のサービスの状態
+const (
+	ConditionStarted               = "service_started"
+	ConditionHealthy               = "service_healthy"
+	ConditionCompletedSuccessfully = "service_completed_successfully"
+)
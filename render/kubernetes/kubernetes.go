@@ -0,0 +1,520 @@
+// Package kubernetes は AppConfig から Kubernetes のマニフェストを生成する
+//
+// サービスからは Deployment と Service、スケーリング設定がある場合は HorizontalPodAutoscaler を生成する
+// ワーカーからは Deployment と HorizontalPodAutoscaler、ジョブからは CronJob、リリースからは Job を生成する
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apispec "github.com/tacokumo/appconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// 生成するオブジェクトに付与するラベル
+const (
+	LabelName      = "app.kubernetes.io/name"
+	LabelInstance  = "app.kubernetes.io/instance"
+	LabelComponent = "app.kubernetes.io/component"
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+)
+
+// managedBy は LabelManagedBy の値
+const managedBy = "appconfig"
+
+// HorizontalPodAutoscaler の Pods メトリクスの名前
+// rps と concurrency のメトリクスはカスタムメトリクスとして提供されている必要がある
+const (
+	// RPSMetricName は1インスタンスあたりの秒間リクエスト数のメトリクスの名前
+	RPSMetricName = "http_requests_per_second"
+	// ConcurrencyMetricName は1インスタンスあたりの同時リクエスト数のメトリクスの名前
+	ConcurrencyMetricName = "http_requests_in_flight"
+)
+
+// ErrNoImage はコンテナイメージが決まらないことを表す
+var ErrNoImage = errors.New("no container image: build.image is not set and Options.Image is empty")
+
+// ErrScaleToZero は scale.min が0のスケーリング設定を HorizontalPodAutoscaler で表せないことを表す
+var ErrScaleToZero = errors.New("scale.min is 0 but HorizontalPodAutoscaler cannot scale to zero: set Options.ClampMinReplicas to use 1 instead")
+
+// ErrDuplicateName は異なるサービス、ワーカー、ジョブ、リリースが同じオブジェクトの名前またはラベルに変換されることを表す
+// 名前は小文字に変換し、使えない文字を置き換え、63文字に切り詰めるため、設定上は異なる名前でも重複しうる
+var ErrDuplicateName = errors.New("duplicate object name")
+
+// ErrNameTooLong はオブジェクトの名前が Kubernetes の上限を超えることを表す
+var ErrNameTooLong = errors.New("object name is too long")
+
+// maxCronJobNameLength は CronJob の名前の長さの上限
+// CronJob から作成される Job の名前には11文字の接尾辞が付くため、63文字より短い
+const maxCronJobNameLength = 52
+
+// Options はマニフェストの生成方法を表す
+type Options struct {
+	// Stage はステージの名前
+	// 指定した場合は AppConfig.ForStage で上書き設定を適用し、オブジェクトの名前とラベルにステージの名前を含める
+	Stage string
+	// Namespace はオブジェクトの名前空間
+	// 空の場合は省略する
+	Namespace string
+	// Image はコンテナイメージ
+	// 空の場合は build.image を使用する
+	// build.dockerfile からイメージをビルドする場合は、ビルドしたイメージを指定しなければならない
+	Image string
+	// ClampMinReplicas は scale.min が0の場合に HorizontalPodAutoscaler の minReplicas を1にするかどうか
+	// false の場合、scale.min が0のスケーリング設定があると ErrScaleToZero を返す
+	ClampMinReplicas bool
+}
+
+// Render は cfg の Kubernetes のオブジェクトを生成する
+// オブジェクトはサービス、ワーカー、ジョブ、リリースの順に並べる
+// cfg はバリデーション済みでなければならない
+//
+// 環境変数のシークレットへの参照 (`secret://<name>/<key>`) は Secret の <name> の <key> への参照に、
+// フィールドへの参照は参照先の値に変換する
+// マシン設定とリソース設定は requests と limits の両方に設定する
+//
+// 名前の変換によってオブジェクトの名前またはラベルが重複する場合は ErrDuplicateName を、
+// CronJob の名前が長すぎる場合は ErrNameTooLong を返す
+func Render(cfg *apispec.AppConfig, opts Options) ([]Object, error) {
+	if opts.Stage != "" {
+		resolved, err := cfg.ForStage(opts.Stage)
+		if err != nil {
+			return nil, err
+		}
+		cfg = &resolved.Config
+	}
+	image := opts.Image
+	if image == "" {
+		image = cfg.Build.Image
+	}
+	if image == "" {
+		return nil, ErrNoImage
+	}
+	r := &renderer{cfg: cfg, opts: opts, image: image}
+	return r.render()
+}
+
+// Marshal は objs を `---` で区切った複数ドキュメントのYAMLに変換する
+func Marshal(objs []Object) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, obj := range objs {
+		if err := enc.Encode(obj); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type renderer struct {
+	cfg   *apispec.AppConfig
+	opts  Options
+	image string
+}
+
+func (r *renderer) render() ([]Object, error) {
+	if err := r.checkNames(); err != nil {
+		return nil, err
+	}
+	var objs []Object
+	svc := &r.cfg.Service
+	container, err := r.container(svc.Name, svc.Command, svc.Env, resourcesFromMachine(svc.MachineConfig))
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", svc.Name, err)
+	}
+	for i, h := range svc.HTTP {
+		container.Ports = append(container.Ports, ContainerPort{Name: portName(i, h.TargetPort), ContainerPort: h.TargetPort})
+	}
+	container.StartupProbe = r.probe(apispec.ProbeStartup)
+	container.ReadinessProbe = r.probe(apispec.ProbeReadiness)
+	container.LivenessProbe = r.probe(apispec.ProbeLiveness)
+	objs = append(objs, r.deployment(svc.Name, container, svc.Scale != nil))
+	if len(svc.HTTP) > 0 {
+		objs = append(objs, r.service())
+	}
+	if svc.Scale != nil {
+		hpa, err := r.autoscaler(svc.Name, svc.Scale)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", svc.Name, err)
+		}
+		objs = append(objs, hpa)
+	}
+
+	for _, w := range r.cfg.Workers {
+		container, err := r.container(w.Name, w.Command, w.Env, resourcesFromMachine(w.MachineConfig))
+		if err != nil {
+			return nil, fmt.Errorf("worker %q: %w", w.Name, err)
+		}
+		objs = append(objs, r.deployment(w.Name, container, w.Scale != nil))
+		if w.Scale != nil {
+			hpa, err := r.autoscaler(w.Name, w.Scale)
+			if err != nil {
+				return nil, fmt.Errorf("worker %q: %w", w.Name, err)
+			}
+			objs = append(objs, hpa)
+		}
+	}
+
+	for _, j := range r.cfg.Jobs {
+		cj, err := r.cronJob(j)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", j.Name, err)
+		}
+		objs = append(objs, cj)
+	}
+
+	for _, rel := range r.cfg.Releases {
+		job, err := r.releaseJob(rel)
+		if err != nil {
+			return nil, fmt.Errorf("release %q: %w", rel.Name, err)
+		}
+		objs = append(objs, job)
+	}
+	return objs, nil
+}
+
+// checkNames はサービス、ワーカー、ジョブ、リリースのオブジェクトの名前と LabelComponent のラベルが重複しないことを確認する
+// 重複する場合は ErrDuplicateName を返す
+func (r *renderer) checkNames() error {
+	names := map[string]string{}
+	components := map[string]string{}
+	claim := func(component, owner string) error {
+		for _, c := range []struct {
+			claimed map[string]string
+			key     string
+		}{{names, r.name(component)}, {components, dnsName(component)}} {
+			if prev, ok := c.claimed[c.key]; ok {
+				return fmt.Errorf("%w: %s and %s both map to %q", ErrDuplicateName, prev, owner, c.key)
+			}
+			c.claimed[c.key] = owner
+		}
+		return nil
+	}
+	if err := claim(r.cfg.Service.Name, fmt.Sprintf("service %q", r.cfg.Service.Name)); err != nil {
+		return err
+	}
+	for _, w := range r.cfg.Workers {
+		if err := claim(w.Name, fmt.Sprintf("worker %q", w.Name)); err != nil {
+			return err
+		}
+	}
+	for _, j := range r.cfg.Jobs {
+		if err := claim(j.Name, fmt.Sprintf("job %q", j.Name)); err != nil {
+			return err
+		}
+	}
+	for _, rel := range r.cfg.Releases {
+		if err := claim(releaseComponent(rel), fmt.Sprintf("release %q", rel.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instance はアプリケーションとステージを表す名前を返す
+func (r *renderer) instance() string {
+	if r.opts.Stage == "" {
+		return dnsName(r.cfg.AppName)
+	}
+	return dnsName(r.cfg.AppName + "-" + r.opts.Stage)
+}
+
+// name は component のオブジェクトの名前を返す (例: `myapp-staging-web`)
+func (r *renderer) name(component string) string {
+	return dnsName(r.instance() + "-" + component)
+}
+
+// selector は component の Pod を選択するラベルを返す
+func (r *renderer) selector(component string) map[string]string {
+	return map[string]string{
+		LabelName:      dnsName(r.cfg.AppName),
+		LabelInstance:  r.instance(),
+		LabelComponent: dnsName(component),
+	}
+}
+
+func (r *renderer) labels(component string) map[string]string {
+	labels := r.selector(component)
+	labels[LabelManagedBy] = managedBy
+	return labels
+}
+
+func (r *renderer) meta(component string) ObjectMeta {
+	return ObjectMeta{Name: r.name(component), Namespace: r.opts.Namespace, Labels: r.labels(component)}
+}
+
+func (r *renderer) deployment(component string, container Container, autoscaled bool) *Deployment {
+	d := &Deployment{
+		TypeMeta:   TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: r.meta(component),
+		Spec: DeploymentSpec{
+			Selector: LabelSelector{MatchLabels: r.selector(component)},
+			Template: PodTemplateSpec{
+				ObjectMeta: ObjectMeta{Labels: r.labels(component)},
+				Spec:       PodSpec{Containers: []Container{container}},
+			},
+		},
+	}
+	if !autoscaled {
+		replicas := 1
+		d.Spec.Replicas = &replicas
+	}
+	return d
+}
+
+func (r *renderer) service() *Service {
+	svc := &r.cfg.Service
+	s := &Service{
+		TypeMeta:   TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: r.meta(svc.Name),
+		Spec:       ServiceSpec{Selector: r.selector(svc.Name)},
+	}
+	for i, h := range svc.HTTP {
+		s.Spec.Ports = append(s.Spec.Ports, ServicePort{Name: portName(i, h.TargetPort), Port: h.TargetPort, TargetPort: h.TargetPort})
+	}
+	return s
+}
+
+// autoscaler は component の Deployment をスケールする HorizontalPodAutoscaler を返す
+// HorizontalPodAutoscaler は0にスケールできないため、scale.min が0の場合は
+// Options.ClampMinReplicas が true なら minReplicas を1にし、そうでなければ ErrScaleToZero を返す
+func (r *renderer) autoscaler(component string, scale *apispec.ServiceScaleConfig) (*HorizontalPodAutoscaler, error) {
+	minReplicas := *scale.Min
+	if minReplicas == 0 {
+		if !r.opts.ClampMinReplicas {
+			return nil, ErrScaleToZero
+		}
+		minReplicas = 1
+	}
+	metric := MetricSpec{}
+	threshold := scale.Metric.Threshold
+	switch scale.Metric.Type {
+	case "cpu", "memory":
+		metric.Type = "Resource"
+		metric.Resource = &ResourceMetricSource{
+			Name:   scale.Metric.Type,
+			Target: MetricTarget{Type: "Utilization", AverageUtilization: threshold},
+		}
+	case "rps", "concurrency":
+		name := RPSMetricName
+		if scale.Metric.Type == "concurrency" {
+			name = ConcurrencyMetricName
+		}
+		metric.Type = "Pods"
+		metric.Pods = &PodsMetricSource{
+			Metric: MetricIdentifier{Name: name},
+			Target: MetricTarget{Type: "AverageValue", AverageValue: strconv.Itoa(threshold)},
+		}
+	}
+	return &HorizontalPodAutoscaler{
+		TypeMeta:   TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: r.meta(component),
+		Spec: HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: r.name(component)},
+			MinReplicas:    minReplicas,
+			MaxReplicas:    scale.Max,
+			Metrics:        []MetricSpec{metric},
+		},
+	}, nil
+}
+
+// releaseJob はリリースのコマンドを1回だけ実行する Job を返す
+// リリースのコマンドは冪等とは限らないため、失敗しても再試行しない
+func (r *renderer) releaseJob(rel apispec.ReleaseConfig) (*Job, error) {
+	component := releaseComponent(rel)
+	container, err := r.container(rel.Name, rel.Action.Command, rel.Env, resourcesFromConfig(rel.Resources))
+	if err != nil {
+		return nil, err
+	}
+	backoffLimit := 0
+	return &Job{
+		TypeMeta:   TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: r.meta(component),
+		Spec: JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     r.jobTemplate(component, container),
+		},
+	}, nil
+}
+
+func (r *renderer) cronJob(j apispec.CronJobConfig) (*CronJob, error) {
+	if name := normalizeName(r.instance() + "-" + j.Name); len(name) > maxCronJobNameLength {
+		return nil, fmt.Errorf("%w: CronJob name %q is %d characters, must be at most %d", ErrNameTooLong, name, len(name), maxCronJobNameLength)
+	}
+	schedule, err := apispec.CronExpression(j.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	container, err := r.container(j.Name, j.Command, j.Env, resourcesFromConfig(j.Resources))
+	if err != nil {
+		return nil, err
+	}
+	return &CronJob{
+		TypeMeta:   TypeMeta{APIVersion: "batch/v1", Kind: "CronJob"},
+		ObjectMeta: r.meta(j.Name),
+		Spec: CronJobSpec{
+			Schedule:          schedule,
+			ConcurrencyPolicy: j.ConcurrencyPolicy,
+			JobTemplate: JobTemplateSpec{Spec: JobSpec{
+				ActiveDeadlineSeconds: seconds(j.Timeout.Std()),
+				Template:              r.jobTemplate(j.Name, container),
+			}},
+		},
+	}, nil
+}
+
+// releaseComponent はリリースの Job の component を返す
+func releaseComponent(rel apispec.ReleaseConfig) string {
+	return "release-" + rel.Name
+}
+
+func (r *renderer) jobTemplate(component string, container Container) PodTemplateSpec {
+	return PodTemplateSpec{
+		ObjectMeta: ObjectMeta{Labels: r.labels(component)},
+		Spec:       PodSpec{RestartPolicy: "Never", Containers: []Container{container}},
+	}
+}
+
+// container は name のコンテナを返す
+// service.http が宣言され、PORT が指定されていない場合は runner と同様に最初の target_port を PORT として渡す
+func (r *renderer) container(name string, command []string, env map[string]apispec.EnvValue, resources *ResourceRequirements) (Container, error) {
+	vars, err := r.env(env)
+	if err != nil {
+		return Container{}, err
+	}
+	return Container{
+		Name:      dnsName(name),
+		Image:     r.image,
+		Command:   slices.Clone(command),
+		Env:       vars,
+		Resources: resources,
+	}, nil
+}
+
+// env は env を名前順の環境変数に変換する
+func (r *renderer) env(env map[string]apispec.EnvValue) ([]EnvVar, error) {
+	values := make(map[string]apispec.EnvValue, len(env))
+	secrets := make(map[string]*SecretKeySelector)
+	for k, v := range env {
+		if name, key, ok := v.SecretRef(); ok {
+			secrets[k] = &SecretKeySelector{Name: name, Key: key}
+			continue
+		}
+		values[k] = v
+	}
+	// シークレットへの参照を除いているため、参照を解決するためのリゾルバは不要
+	resolved, err := r.cfg.ResolveEnv(context.Background(), values, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := env["PORT"]; !ok && len(r.cfg.Service.HTTP) > 0 {
+		resolved["PORT"] = strconv.Itoa(r.cfg.Service.HTTP[0].TargetPort)
+	}
+	names := slices.Sorted(maps.Keys(resolved))
+	names = append(names, slices.Collect(maps.Keys(secrets))...)
+	slices.Sort(names)
+	vars := make([]EnvVar, len(names))
+	for i, name := range names {
+		if ref, ok := secrets[name]; ok {
+			vars[i] = EnvVar{Name: name, ValueFrom: &EnvVarSource{SecretKeyRef: ref}}
+			continue
+		}
+		vars[i] = EnvVar{Name: name, Value: resolved[name]}
+	}
+	return vars, nil
+}
+
+// probe は role のヘルスチェックを Probe に変換する
+// Kubernetes は liveness と startup の successThreshold に1のみを許可するため、それらでは success_threshold を無視する
+func (r *renderer) probe(role apispec.ProbeRole) *Probe {
+	svc := &r.cfg.Service
+	h := svc.Probe(role)
+	if h == nil {
+		return nil
+	}
+	t := h.Timings()
+	p := &Probe{
+		InitialDelaySeconds: seconds(t.InitialDelay),
+		PeriodSeconds:       max(seconds(t.Interval), 1),
+		TimeoutSeconds:      max(seconds(t.Timeout), 1),
+		SuccessThreshold:    t.SuccessThreshold,
+		FailureThreshold:    t.FailureThreshold,
+	}
+	if role != apispec.ProbeReadiness {
+		p.SuccessThreshold = 1
+	}
+	port := svc.HealthcheckPort(h)
+	switch h.Kind() {
+	case apispec.HealthcheckHTTP:
+		p.HTTPGet = &HTTPGetAction{Path: h.HTTP.Path, Port: port}
+		for _, name := range slices.Sorted(maps.Keys(h.HTTP.Headers)) {
+			p.HTTPGet.HTTPHeaders = append(p.HTTPGet.HTTPHeaders, HTTPHeader{Name: name, Value: h.HTTP.Headers[name]})
+		}
+	case apispec.HealthcheckProcess:
+		p.Exec = &ExecAction{Command: slices.Clone(h.Process.Command)}
+	case apispec.HealthcheckTCP:
+		p.TCPSocket = &TCPSocketAction{Port: port}
+	case apispec.HealthcheckGRPC:
+		p.GRPC = &GRPCAction{Port: port, Service: h.GRPC.Service}
+	}
+	return p
+}
+
+func resourcesFromMachine(m *apispec.MachineConfig) *ResourceRequirements {
+	if m == nil {
+		return nil
+	}
+	return resourcesFromConfig(apispec.ResourceConfig{CPU: m.CPU, Memory: m.Memory})
+}
+
+func resourcesFromConfig(c apispec.ResourceConfig) *ResourceRequirements {
+	list := map[string]string{"cpu": c.CPU.String(), "memory": c.Memory.String()}
+	return &ResourceRequirements{Requests: list, Limits: maps.Clone(list)}
+}
+
+// portName は i 番目の target_port の名前を返す
+func portName(i, port int) string {
+	if i == 0 {
+		return "http"
+	}
+	return "http-" + strconv.Itoa(port)
+}
+
+// seconds は d を秒単位に切り上げる
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// dnsName は s を Kubernetes のオブジェクトの名前に使える DNS ラベルに変換する
+// normalizeName の結果を63文字に切り詰める
+func dnsName(s string) string {
+	name := normalizeName(s)
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-")
+	}
+	return name
+}
+
+// normalizeName は s を小文字に変換し、英小文字、数字、`-` 以外の文字を `-` に置き換える
+// 先頭と末尾の `-` は取り除く
+func normalizeName(s string) string {
+	b := []byte(strings.ToLower(s))
+	for i, c := range b {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			b[i] = '-'
+		}
+	}
+	return strings.Trim(string(b), "-")
+}
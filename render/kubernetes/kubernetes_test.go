@@ -0,0 +1,206 @@
+package kubernetes
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRender_Golden(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		file   string
+		opts   Options
+		golden string
+	}{
+		{
+			name:   "サービス、Service、リリースのJobを生成する",
+			file:   "../../testdata/valid.yaml",
+			golden: "valid.golden.yaml",
+		},
+		{
+			name:   "ステージの上書き設定を適用し、名前にステージ名を含める",
+			file:   "../../testdata/stages.yaml",
+			opts:   Options{Stage: "staging", Namespace: "myapp", Image: "registry.example.com/myapp:abc123", ClampMinReplicas: true},
+			golden: "stages-staging.golden.yaml",
+		},
+		{
+			name:   "ワーカーのDeploymentとジョブのCronJobを生成する",
+			file:   "../../testdata/processes.yaml",
+			golden: "processes.golden.yaml",
+		},
+		{
+			name:   "役割ごとのヘルスチェックをProbeに変換する",
+			file:   "testdata/probes.yaml",
+			opts:   Options{ClampMinReplicas: true},
+			golden: "probes.golden.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := apispec.LoadFile(tt.file)
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			objs, err := Render(cfg, tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			got, err := Marshal(objs)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run `go test ./render/kubernetes -update` to create it)", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Render() does not match %s (run `go test ./render/kubernetes -update` to update it):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	t.Parallel()
+	base := func() *apispec.AppConfig {
+		return &apispec.AppConfig{
+			AppName:  "myapp",
+			Build:    apispec.BuildConfig{Dockerfile: "Dockerfile"},
+			Releases: []apispec.ReleaseConfig{},
+			Service:  apispec.ServiceConfig{Name: "web", Command: []string{"start"}},
+		}
+	}
+
+	t.Run("イメージが決まらない場合、ErrNoImageを返す", func(t *testing.T) {
+		t.Parallel()
+		if _, err := Render(base(), Options{}); !errors.Is(err, ErrNoImage) {
+			t.Errorf("Render() error = %v, want ErrNoImage", err)
+		}
+	})
+	t.Run("存在しないステージの場合、ErrStageNotFoundを返す", func(t *testing.T) {
+		t.Parallel()
+		if _, err := Render(base(), Options{Stage: "qa", Image: "myapp:latest"}); !errors.Is(err, apispec.ErrStageNotFound) {
+			t.Errorf("Render() error = %v, want ErrStageNotFound", err)
+		}
+	})
+	t.Run("scale.minが0の場合、ErrScaleToZeroを返す", func(t *testing.T) {
+		t.Parallel()
+		cfg := base()
+		minInstances := 0
+		cfg.Service.Scale = &apispec.ServiceScaleConfig{Min: &minInstances, Max: 3, Metric: apispec.ServiceMetricConfig{Type: "cpu", Threshold: 80}}
+		if _, err := Render(cfg, Options{Image: "myapp:latest"}); !errors.Is(err, ErrScaleToZero) {
+			t.Errorf("Render() error = %v, want ErrScaleToZero", err)
+		}
+	})
+	t.Run("CronJobの名前が52文字を超える場合、ErrNameTooLongを返す", func(t *testing.T) {
+		t.Parallel()
+		cfg := base()
+		cfg.Jobs = []apispec.CronJobConfig{{Name: strings.Repeat("sync", 12), Schedule: "@daily", Command: []string{"sync"}}}
+		if _, err := Render(cfg, Options{Image: "myapp:latest"}); !errors.Is(err, ErrNameTooLong) {
+			t.Errorf("Render() error = %v, want ErrNameTooLong", err)
+		}
+	})
+	t.Run("cron式に変換できないスケジュールの場合、失敗する", func(t *testing.T) {
+		t.Parallel()
+		cfg := base()
+		cfg.Jobs = []apispec.CronJobConfig{{Name: "sync", Schedule: "@every 7m", Command: []string{"sync"}}}
+		if _, err := Render(cfg, Options{Image: "myapp:latest"}); err == nil {
+			t.Error("Render() error = nil, want error")
+		}
+	})
+}
+
+func TestRender_DuplicateName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		modify func(cfg *apispec.AppConfig)
+	}{
+		{
+			name: "大文字と小文字だけが異なる場合、ErrDuplicateNameを返す",
+			modify: func(cfg *apispec.AppConfig) {
+				cfg.Workers = []apispec.WorkerConfig{{Name: "Web", Command: []string{"work"}}}
+			},
+		},
+		{
+			name: "使えない文字を置き換えると同じになる場合、ErrDuplicateNameを返す",
+			modify: func(cfg *apispec.AppConfig) {
+				cfg.Workers = []apispec.WorkerConfig{{Name: "mail_sender", Command: []string{"work"}}, {Name: "mail.sender", Command: []string{"work"}}}
+			},
+		},
+		{
+			name: "ワーカーの名前がリリースのJobのcomponentと同じ場合、ErrDuplicateNameを返す",
+			modify: func(cfg *apispec.AppConfig) {
+				cfg.Workers = []apispec.WorkerConfig{{Name: "release-migrate", Command: []string{"work"}}}
+				cfg.Releases = []apispec.ReleaseConfig{{Name: "migrate", Action: apispec.ReleaseActionConfig{Command: []string{"migrate"}}}}
+			},
+		},
+		{
+			name: "63文字に切り詰めると同じになる場合、ErrDuplicateNameを返す",
+			modify: func(cfg *apispec.AppConfig) {
+				long := strings.Repeat("worker", 11)
+				cfg.Workers = []apispec.WorkerConfig{{Name: long + "-a", Command: []string{"work"}}, {Name: long + "-b", Command: []string{"work"}}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &apispec.AppConfig{
+				AppName:  "myapp",
+				Build:    apispec.BuildConfig{Image: "myapp:latest"},
+				Releases: []apispec.ReleaseConfig{},
+				Service:  apispec.ServiceConfig{Name: "web", Command: []string{"start"}},
+			}
+			tt.modify(cfg)
+			if _, err := Render(cfg, Options{}); !errors.Is(err, ErrDuplicateName) {
+				t.Errorf("Render() error = %v, want ErrDuplicateName", err)
+			}
+		})
+	}
+}
+
+func TestRender_Schedules(t *testing.T) {
+	t.Parallel()
+	// バリデーションを通るスケジュールは必ず CronJob に変換できなければならない
+	schedules := []string{
+		"0 3 * * *", "*/5 * * * *", "0 9 * * MON-FRI", "@yearly", "@monthly", "@weekly", "@daily", "@hourly",
+		"@every 1m", "@every 15m", "@every 1h", "@every 6h", "@every 24h",
+		"@every 90s", "@every 7m", "@every 48h", "TZ=Asia/Tokyo 0 3 * * *", "CRON_TZ=UTC 0 3 * * *",
+	}
+
+	for _, schedule := range schedules {
+		t.Run(schedule, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := apispec.LoadFile("../../testdata/processes.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Jobs[0].Schedule = schedule
+			if err := cfg.Validate(); err != nil {
+				return
+			}
+			if _, err := Render(cfg, Options{Image: "myapp:latest"}); err != nil {
+				t.Errorf("Render() error = %v for a schedule that passes validation", err)
+			}
+		})
+	}
+}
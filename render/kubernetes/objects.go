@@ -0,0 +1,230 @@
+package kubernetes
+
+// このファイルの型は Kubernetes API のオブジェクトのうち、生成するマニフェストに必要なフィールドのみを表す
+// フィールド名とタグは Kubernetes API に合わせている
+
+// Object は生成する Kubernetes のオブジェクトを表す
+type Object interface {
+	// GetTypeMeta はオブジェクトの種類を返す
+	GetTypeMeta() TypeMeta
+	// GetObjectMeta はオブジェクトのメタデータを返す
+	GetObjectMeta() *ObjectMeta
+}
+
+// TypeMeta はオブジェクトの種類を表す
+type TypeMeta struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// GetTypeMeta はオブジェクトの種類を返す
+func (t TypeMeta) GetTypeMeta() TypeMeta {
+	return t
+}
+
+// ObjectMeta はオブジェクトのメタデータを表す
+type ObjectMeta struct {
+	Name      string            `yaml:"name,omitempty"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+// GetObjectMeta はオブジェクトのメタデータを返す
+func (m *ObjectMeta) GetObjectMeta() *ObjectMeta {
+	return m
+}
+
+// Deployment は `apps/v1` の Deployment を表す
+type Deployment struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       DeploymentSpec `yaml:"spec"`
+}
+
+type DeploymentSpec struct {
+	// Replicas は HorizontalPodAutoscaler を生成する場合は省略する
+	Replicas *int            `yaml:"replicas,omitempty"`
+	Selector LabelSelector   `yaml:"selector"`
+	Template PodTemplateSpec `yaml:"template"`
+}
+
+type LabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type PodTemplateSpec struct {
+	ObjectMeta `yaml:"metadata"`
+	Spec       PodSpec `yaml:"spec"`
+}
+
+type PodSpec struct {
+	RestartPolicy string      `yaml:"restartPolicy,omitempty"`
+	Containers    []Container `yaml:"containers"`
+}
+
+type Container struct {
+	Name           string                `yaml:"name"`
+	Image          string                `yaml:"image"`
+	Command        []string              `yaml:"command,omitempty"`
+	Ports          []ContainerPort       `yaml:"ports,omitempty"`
+	Env            []EnvVar              `yaml:"env,omitempty"`
+	Resources      *ResourceRequirements `yaml:"resources,omitempty"`
+	StartupProbe   *Probe                `yaml:"startupProbe,omitempty"`
+	ReadinessProbe *Probe                `yaml:"readinessProbe,omitempty"`
+	LivenessProbe  *Probe                `yaml:"livenessProbe,omitempty"`
+}
+
+type ContainerPort struct {
+	Name          string `yaml:"name,omitempty"`
+	ContainerPort int    `yaml:"containerPort"`
+}
+
+type EnvVar struct {
+	Name      string        `yaml:"name"`
+	Value     string        `yaml:"value,omitempty"`
+	ValueFrom *EnvVarSource `yaml:"valueFrom,omitempty"`
+}
+
+type EnvVarSource struct {
+	SecretKeyRef *SecretKeySelector `yaml:"secretKeyRef,omitempty"`
+}
+
+type SecretKeySelector struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+type ResourceRequirements struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+type Probe struct {
+	HTTPGet             *HTTPGetAction   `yaml:"httpGet,omitempty"`
+	Exec                *ExecAction      `yaml:"exec,omitempty"`
+	TCPSocket           *TCPSocketAction `yaml:"tcpSocket,omitempty"`
+	GRPC                *GRPCAction      `yaml:"grpc,omitempty"`
+	InitialDelaySeconds int              `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int              `yaml:"periodSeconds"`
+	TimeoutSeconds      int              `yaml:"timeoutSeconds"`
+	SuccessThreshold    int              `yaml:"successThreshold"`
+	FailureThreshold    int              `yaml:"failureThreshold"`
+}
+
+type HTTPGetAction struct {
+	Path        string       `yaml:"path"`
+	Port        int          `yaml:"port"`
+	HTTPHeaders []HTTPHeader `yaml:"httpHeaders,omitempty"`
+}
+
+type HTTPHeader struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+type TCPSocketAction struct {
+	Port int `yaml:"port"`
+}
+
+type GRPCAction struct {
+	Port    int    `yaml:"port"`
+	Service string `yaml:"service,omitempty"`
+}
+
+// Service は `v1` の Service を表す
+type Service struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       ServiceSpec `yaml:"spec"`
+}
+
+type ServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []ServicePort     `yaml:"ports"`
+}
+
+type ServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+}
+
+// HorizontalPodAutoscaler は `autoscaling/v2` の HorizontalPodAutoscaler を表す
+type HorizontalPodAutoscaler struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       HorizontalPodAutoscalerSpec `yaml:"spec"`
+}
+
+type HorizontalPodAutoscalerSpec struct {
+	ScaleTargetRef CrossVersionObjectReference `yaml:"scaleTargetRef"`
+	MinReplicas    int                         `yaml:"minReplicas"`
+	MaxReplicas    int                         `yaml:"maxReplicas"`
+	Metrics        []MetricSpec                `yaml:"metrics"`
+}
+
+type CrossVersionObjectReference struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+}
+
+type MetricSpec struct {
+	Type     string                `yaml:"type"`
+	Resource *ResourceMetricSource `yaml:"resource,omitempty"`
+	Pods     *PodsMetricSource     `yaml:"pods,omitempty"`
+}
+
+type ResourceMetricSource struct {
+	Name   string       `yaml:"name"`
+	Target MetricTarget `yaml:"target"`
+}
+
+type PodsMetricSource struct {
+	Metric MetricIdentifier `yaml:"metric"`
+	Target MetricTarget     `yaml:"target"`
+}
+
+type MetricIdentifier struct {
+	Name string `yaml:"name"`
+}
+
+type MetricTarget struct {
+	Type               string `yaml:"type"`
+	AverageUtilization int    `yaml:"averageUtilization,omitempty"`
+	AverageValue       string `yaml:"averageValue,omitempty"`
+}
+
+// Job は `batch/v1` の Job を表す
+type Job struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       JobSpec `yaml:"spec"`
+}
+
+type JobSpec struct {
+	BackoffLimit          *int            `yaml:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds int             `yaml:"activeDeadlineSeconds,omitempty"`
+	Template              PodTemplateSpec `yaml:"template"`
+}
+
+// CronJob は `batch/v1` の CronJob を表す
+type CronJob struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       CronJobSpec `yaml:"spec"`
+}
+
+type CronJobSpec struct {
+	Schedule          string          `yaml:"schedule"`
+	ConcurrencyPolicy string          `yaml:"concurrencyPolicy,omitempty"`
+	JobTemplate       JobTemplateSpec `yaml:"jobTemplate"`
+}
+
+type JobTemplateSpec struct {
+	Spec JobSpec `yaml:"spec"`
+}
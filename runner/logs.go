@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// logMux は複数のプロセスの出力を行単位で1つの出力先にまとめる
+// 行が混ざらないよう、同じ出力先への書き込みは排他的に行う
+type logMux struct {
+	mu    sync.Mutex
+	width int
+}
+
+// prefixWriter は行の先頭にプロセスの名前を付けて w に書き込む
+// 改行で終わらない出力は次の書き込みまたは Flush まで保持する
+type prefixWriter struct {
+	mux    *logMux
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+// writer は name の出力を w に書き込む io.Writer を返す
+func (m *logMux) writer(w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{mux: m, w: w, prefix: fmt.Sprintf("%-*s | ", m.width, name)}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mux.mu.Lock()
+	defer p.mux.mu.Unlock()
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return len(b), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush は保持している改行で終わらない出力を書き込む
+func (p *prefixWriter) Flush() error {
+	p.mux.mu.Lock()
+	defer p.mux.mu.Unlock()
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	if _, err := io.WriteString(p.w, p.prefix); err != nil {
+		return err
+	}
+	_, err := p.w.Write(line)
+	return err
+}
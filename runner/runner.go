@@ -0,0 +1,316 @@
+// Package runner は AppConfig に記述されたアプリケーションをローカルで起動する
+//
+// リリースのコマンドを順に実行した後にサービスを起動し、ヘルスチェックが成功するまで待つ
+// 各プロセスの出力には名前が付けられ、シグナルはサービスに転送される
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	apispec "github.com/tacokumo/appconfig"
+	"github.com/tacokumo/appconfig/probe"
+)
+
+// DefaultShutdownTimeout は Options.ShutdownTimeout のデフォルト値
+const DefaultShutdownTimeout = 10 * time.Second
+
+// runnerLogName は Runner 自身のログに付ける名前
+const runnerLogName = "runner"
+
+// Options は Runner の動作を表す
+type Options struct {
+	// Dir はコマンドを実行するディレクトリ
+	// 空の場合はカレントディレクトリで実行する
+	Dir string
+	// Env はすべてのプロセスに渡す環境変数 (`KEY=value` 形式)
+	// nil の場合は現在のプロセスの環境変数を引き継ぐ
+	Env []string
+	// Stdout と Stderr はプロセスと Runner の出力先
+	// nil の場合は os.Stdout と os.Stderr
+	Stdout io.Writer
+	Stderr io.Writer
+	// Secrets は環境変数のシークレットへの参照を解決する
+	// nil の場合、シークレットへの参照はエラーになる
+	Secrets apispec.SecretResolver
+	// Restart はサービスが終了した場合の再起動の方針
+	Restart RestartPolicy
+	// MaxRestarts はサービスの再起動の回数の上限
+	// 0 の場合は上限なし
+	MaxRestarts int
+	// RestartDelay はサービスの再起動までの待ち時間
+	RestartDelay time.Duration
+	// ShutdownTimeout は SIGTERM を送信してから強制終了するまでの待ち時間
+	// 0 の場合は DefaultShutdownTimeout
+	ShutdownTimeout time.Duration
+	// Host はヘルスチェックの対象のホスト名
+	// 空の場合は `127.0.0.1`
+	Host string
+	// Signals は受信したシグナル
+	// SIGINT と SIGTERM はサービスを停止し、それ以外のシグナルはサービスに転送する
+	Signals <-chan os.Signal
+}
+
+// Runner は AppConfig のリリースとサービスをローカルで実行する
+type Runner struct {
+	cfg    *apispec.AppConfig
+	opts   Options
+	logs   *logMux
+	status *prefixWriter
+}
+
+// New は cfg を opts に従って実行する Runner を作成する
+// cfg はバリデーション済みでなければならない
+func New(cfg *apispec.AppConfig, opts Options) *Runner {
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	if opts.Env == nil {
+		opts.Env = os.Environ()
+	}
+	if opts.ShutdownTimeout == 0 {
+		opts.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if opts.Host == "" {
+		opts.Host = "127.0.0.1"
+	}
+	names := []string{runnerLogName, cfg.Service.Name}
+	for _, r := range cfg.Releases {
+		names = append(names, releaseLogName(r))
+	}
+	width := len(slices.MaxFunc(names, func(a, b string) int { return len(a) - len(b) }))
+	logs := &logMux{width: width}
+	return &Runner{cfg: cfg, opts: opts, logs: logs, status: logs.writer(opts.Stderr, runnerLogName)}
+}
+
+// Run はリリースのコマンドを順に実行した後、サービスを起動する
+// サービスは ctx が終了するか、SIGINT または SIGTERM を受信するまで実行され、その場合は nil を返す
+func (r *Runner) Run(ctx context.Context) error {
+	if err := r.RunReleases(ctx); err != nil {
+		return err
+	}
+	return r.RunService(ctx)
+}
+
+// RunReleases はリリースのコマンドを宣言された順に実行する
+// いずれかのコマンドが失敗した場合は残りのコマンドを実行せずにエラーを返す
+func (r *Runner) RunReleases(ctx context.Context) error {
+	for _, rel := range r.cfg.Releases {
+		env, err := r.environ(ctx, rel.Env)
+		if err != nil {
+			return fmt.Errorf("release %q: %w", rel.Name, err)
+		}
+		r.logf("running release %s: %v", rel.Name, rel.Action.Command)
+		name := releaseLogName(rel)
+		stdout, stderr := r.logs.writer(r.opts.Stdout, name), r.logs.writer(r.opts.Stderr, name)
+		command := rel.Action.Command
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Dir = r.opts.Dir
+		cmd.Env = env
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+		cmd.WaitDelay = r.opts.ShutdownTimeout
+		err = cmd.Run()
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+		if err != nil {
+			return fmt.Errorf("release %q: %w", rel.Name, err)
+		}
+	}
+	return nil
+}
+
+// RunService はサービスを起動し、startup と readiness のヘルスチェックが成功するまで待つ
+// startup のヘルスチェックが失敗した場合はサービスを停止してエラーを返す
+// liveness のヘルスチェックが失敗した場合はサービスを再起動する
+// サービスは ctx が終了するか、SIGINT または SIGTERM を受信するまで実行され、その場合は nil を返す
+func (r *Runner) RunService(ctx context.Context) error {
+	svc := &r.cfg.Service
+	env, err := r.environ(ctx, svc.Env)
+	if err != nil {
+		return fmt.Errorf("service %q: %w", svc.Name, err)
+	}
+	stdout, stderr := r.logs.writer(r.opts.Stdout, svc.Name), r.logs.writer(r.opts.Stderr, svc.Name)
+	defer func() {
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+	}()
+	sup := &Supervisor{
+		Process: Process{
+			Name:    svc.Name,
+			Command: svc.Command,
+			Dir:     r.opts.Dir,
+			Env:     env,
+			Stdout:  stdout,
+			Stderr:  stderr,
+		},
+		Policy:       r.opts.Restart,
+		MaxRestarts:  r.opts.MaxRestarts,
+		RestartDelay: r.opts.RestartDelay,
+		Logf:         r.logf,
+	}
+	r.logf("starting service %s: %v", svc.Name, svc.Command)
+	if err := sup.Start(); err != nil {
+		return fmt.Errorf("service %q: %w", svc.Name, err)
+	}
+
+	probeCtx, cancelProbes := context.WithCancel(ctx)
+	defer cancelProbes()
+	ready := make(chan error, 1)
+	go func() { ready <- r.waitReady(probeCtx, env) }()
+	var unhealthy <-chan probe.Result
+
+	for {
+		select {
+		case <-ctx.Done():
+			return r.stop(sup)
+		case sig := <-r.opts.Signals:
+			if sig == os.Interrupt || sig == syscall.SIGTERM {
+				r.logf("received %s, stopping", sig)
+				return r.stop(sup)
+			}
+			if err := sup.Signal(sig); err != nil {
+				r.logf("forwarding %s: %v", sig, err)
+			}
+		case <-sup.Done():
+			if err := sup.Err(); err != nil {
+				return fmt.Errorf("service %q exited: %w", svc.Name, err)
+			}
+			return fmt.Errorf("service %q exited", svc.Name)
+		case err := <-ready:
+			if err != nil {
+				_ = r.stop(sup)
+				return fmt.Errorf("service %q: %w", svc.Name, err)
+			}
+			r.logf("service %s is ready", svc.Name)
+			unhealthy, err = r.watchLiveness(probeCtx, env)
+			if err != nil {
+				_ = r.stop(sup)
+				return fmt.Errorf("service %q: %w", svc.Name, err)
+			}
+		case res, ok := <-unhealthy:
+			if !ok {
+				unhealthy = nil
+				continue
+			}
+			r.logf("liveness check failed: %v, restarting %s", res.Err, svc.Name)
+			if err := sup.Restart(); err != nil {
+				r.logf("restarting %s: %v", svc.Name, err)
+			}
+		}
+	}
+}
+
+// waitReady は startup と readiness のヘルスチェックが順に成功するまで待つ
+// startup のヘルスチェックが FailureThreshold 回連続で失敗した場合はエラーを返す
+// readiness のヘルスチェックは失敗しても諦めず、成功するか ctx が終了するまで繰り返す
+// ヘルスチェックが宣言されていない場合はすぐに返る
+func (r *Runner) waitReady(ctx context.Context, env []string) error {
+	startup, err := r.prober(apispec.ProbeStartup, env)
+	if err != nil {
+		return err
+	}
+	if startup != nil {
+		if err := startup.WaitHealthy(ctx); err != nil {
+			return fmt.Errorf("%s check: %w", apispec.ProbeStartup, err)
+		}
+	}
+	readiness, err := r.prober(apispec.ProbeReadiness, env)
+	if err != nil || readiness == nil {
+		return err
+	}
+	for res := range readiness.Run(ctx) {
+		switch {
+		case res.Status == probe.StatusHealthy:
+			return nil
+		case res.Changed && res.Status == probe.StatusUnhealthy:
+			r.logf("%s check failed: %v, waiting for %s", apispec.ProbeReadiness, res.Err, r.cfg.Service.Name)
+		}
+	}
+	return ctx.Err()
+}
+
+// watchLiveness は liveness のヘルスチェックを開始し、状態が StatusUnhealthy に変化した結果を送信するチャネルを返す
+// ヘルスチェックが宣言されていない場合は nil を返す
+func (r *Runner) watchLiveness(ctx context.Context, env []string) (<-chan probe.Result, error) {
+	p, err := r.prober(apispec.ProbeLiveness, env)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	unhealthy := make(chan probe.Result)
+	go func() {
+		defer close(unhealthy)
+		for res := range p.Run(ctx) {
+			if !res.Changed || res.Status != probe.StatusUnhealthy {
+				continue
+			}
+			select {
+			case unhealthy <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return unhealthy, nil
+}
+
+// prober は role のヘルスチェックを実行する Prober を返す
+// ヘルスチェックが宣言されていない場合は nil を返す
+func (r *Runner) prober(role apispec.ProbeRole, env []string) (*probe.Prober, error) {
+	svc := &r.cfg.Service
+	h := svc.Probe(role)
+	if h == nil {
+		return nil, nil
+	}
+	return probe.New(h, probe.Target{
+		Host: r.opts.Host,
+		Port: svc.HealthcheckPort(h),
+		Dir:  r.opts.Dir,
+		Env:  env,
+	})
+}
+
+// stop はサービスを停止する
+func (r *Runner) stop(sup *Supervisor) error {
+	r.logf("stopping service %s", sup.Process.Name)
+	return sup.Stop(r.opts.ShutdownTimeout)
+}
+
+// environ はプロセスに渡す環境変数を返す
+// Options.Env に env の参照を解決した値を追加する
+// service.http が宣言され、PORT が指定されていない場合は最初の target_port を PORT として渡す
+func (r *Runner) environ(ctx context.Context, env map[string]apispec.EnvValue) ([]string, error) {
+	resolved, err := r.cfg.ResolveEnv(ctx, env, r.opts.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resolved["PORT"]; !ok && len(r.cfg.Service.HTTP) > 0 {
+		resolved["PORT"] = strconv.Itoa(r.cfg.Service.HTTP[0].TargetPort)
+	}
+	environ := slices.Clone(r.opts.Env)
+	for _, k := range slices.Sorted(maps.Keys(resolved)) {
+		environ = append(environ, k+"="+resolved[k])
+	}
+	return environ, nil
+}
+
+func (r *Runner) logf(format string, args ...any) {
+	_, _ = fmt.Fprintf(r.status, format+"\n", args...)
+}
+
+// releaseLogName はリリースの出力に付ける名前を返す
+func releaseLogName(r apispec.ReleaseConfig) string {
+	return "release:" + r.Name
+}
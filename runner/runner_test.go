@@ -0,0 +1,441 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	apispec "github.com/tacokumo/appconfig"
+)
+
+// TestHelperProcess はテストから起動される小さなプロセスとして動作する
+// GO_WANT_HELPER_PROCESS が設定されていない場合は何もしない
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "no helper command")
+		os.Exit(2)
+	}
+	os.Exit(helperMain(args[1], args[2:]))
+}
+
+func helperMain(mode string, args []string) int {
+	switch mode {
+	case "append":
+		// append FILE TEXT は FILE に TEXT を追記する
+		f, err := os.OpenFile(args[0], os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		fmt.Fprintln(f, args[1])
+		fmt.Printf("%s done", args[1])
+		return 0
+	case "fail":
+		fmt.Fprintln(os.Stderr, "boom")
+		return 3
+	case "flaky":
+		// flaky FILE は FILE が存在しない場合は作成して異常終了し、存在する場合は serve と同じ動作をする
+		if _, err := os.Stat(args[0]); err != nil {
+			_ = os.WriteFile(args[0], nil, 0o644)
+			fmt.Println("crashing")
+			return 1
+		}
+		return serve()
+	case "serve":
+		return serve()
+	case "slow-serve":
+		// slow-serve DURATION は DURATION だけ待ってから serve と同じ動作をする
+		d, err := time.ParseDuration(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		time.Sleep(d)
+		return serve()
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Println("ignoring")
+		time.Sleep(time.Minute)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown helper command %q\n", mode)
+		return 2
+	}
+}
+
+// serve は PORT でHTTPリクエストを受け付け、SIGTERM を受信すると終了する
+func serve() int {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP)
+	l, err := net.Listen("tcp", "127.0.0.1:"+os.Getenv("PORT"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	fmt.Println("listening")
+	for sig := range sigs {
+		if sig == syscall.SIGHUP {
+			fmt.Println("hup")
+			continue
+		}
+		fmt.Println("bye")
+		return 0
+	}
+	return 0
+}
+
+// helperCommand は TestHelperProcess を mode で起動するコマンドを返す
+func helperCommand(mode string, args ...string) []string {
+	return append([]string{os.Args[0], "-test.run=^TestHelperProcess$", "--", mode}, args...)
+}
+
+func helperEnv() []string {
+	return append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+}
+
+// syncBuffer は並行に書き込める bytes.Buffer
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor は b に s が出力されるまで待つ
+func waitFor(t *testing.T, b *syncBuffer, s string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(b.String(), s) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q in output:\n%s", s, b.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// freePort は使用されていないポートを返す
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func release(name string, command []string) apispec.ReleaseConfig {
+	return apispec.ReleaseConfig{
+		Name:      name,
+		Resources: apispec.ResourceConfig{CPU: apispec.MustParseQuantity("100m"), Memory: apispec.MustParseQuantity("64Mi")},
+		Action:    apispec.ReleaseActionConfig{Command: command},
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+	record := filepath.Join(t.TempDir(), "releases.txt")
+	cfg := &apispec.AppConfig{
+		AppName: "myapp",
+		Build:   apispec.BuildConfig{Image: "myapp:latest"},
+		Releases: []apispec.ReleaseConfig{
+			release("migrate", helperCommand("append", record, "migrate")),
+			release("seed", helperCommand("append", record, "seed")),
+		},
+		Service: apispec.ServiceConfig{
+			Name:        "web",
+			Command:     helperCommand("serve"),
+			HTTP:        []apispec.ServiceHTTPConfig{{TargetPort: freePort(t)}},
+			Healthcheck: &apispec.HealthcheckConfig{HTTP: &apispec.HealthcheckHTTPConfig{Path: "/healthz"}, Interval: apispec.MustParseDuration("20ms")},
+		},
+	}
+	var out syncBuffer
+	signals := make(chan os.Signal, 1)
+	r := New(cfg, Options{Env: helperEnv(), Stdout: &out, Stderr: &out, Signals: signals})
+
+	errc := make(chan error, 1)
+	go func() { errc <- r.Run(context.Background()) }()
+
+	waitFor(t, &out, "service web is ready")
+	signals <- syscall.SIGHUP
+	waitFor(t, &out, "web             | hup\n")
+	signals <- syscall.SIGTERM
+	if err := <-errc; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got, err := os.ReadFile(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "migrate\nseed\n" {
+		t.Errorf("releases ran as %q, want migrate then seed", got)
+	}
+	for _, want := range []string{
+		"release:migrate | migrate done\n",
+		"release:seed    | seed done\n",
+		"web             | listening\n",
+		"web             | bye\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunner_Run_ReleaseFailure(t *testing.T) {
+	t.Parallel()
+	record := filepath.Join(t.TempDir(), "releases.txt")
+	cfg := &apispec.AppConfig{
+		AppName: "myapp",
+		Build:   apispec.BuildConfig{Image: "myapp:latest"},
+		Releases: []apispec.ReleaseConfig{
+			release("migrate", helperCommand("fail")),
+			release("seed", helperCommand("append", record, "seed")),
+		},
+		Service: apispec.ServiceConfig{Name: "web", Command: helperCommand("serve")},
+	}
+	var out syncBuffer
+	r := New(cfg, Options{Env: helperEnv(), Stdout: &out, Stderr: &out})
+
+	err := r.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `release "migrate"`) {
+		t.Fatalf("Run() error = %v, want the migrate release to fail", err)
+	}
+	if _, err := os.Stat(record); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("seed release ran after migrate failed")
+	}
+	if !strings.Contains(out.String(), "release:migrate | boom\n") {
+		t.Errorf("output does not contain the release stderr:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "starting service") {
+		t.Errorf("service started after a release failed:\n%s", out.String())
+	}
+}
+
+func TestRunner_Run_ContextCanceled(t *testing.T) {
+	t.Parallel()
+	cfg := &apispec.AppConfig{
+		AppName:  "myapp",
+		Build:    apispec.BuildConfig{Image: "myapp:latest"},
+		Releases: []apispec.ReleaseConfig{},
+		Service: apispec.ServiceConfig{
+			Name:    "web",
+			Command: helperCommand("serve"),
+			HTTP:    []apispec.ServiceHTTPConfig{{TargetPort: freePort(t)}},
+			Probes: &apispec.ProbesConfig{
+				Readiness: &apispec.HealthcheckConfig{TCP: &apispec.HealthcheckTCPConfig{}, Interval: apispec.MustParseDuration("20ms")},
+			},
+		},
+	}
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	r := New(cfg, Options{Env: helperEnv(), Stdout: &out, Stderr: &out})
+
+	errc := make(chan error, 1)
+	go func() { errc <- r.Run(ctx) }()
+	waitFor(t, &out, "service web is ready")
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	waitFor(t, &out, "web    | bye\n")
+}
+
+func TestRunner_Run_Probes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		probes  func(unused int) *apispec.ProbesConfig
+		wantErr string
+	}{
+		{
+			name: "readinessが失敗し続けても、サービスが応答するまで待つ",
+			probes: func(int) *apispec.ProbesConfig {
+				return &apispec.ProbesConfig{
+					Readiness: &apispec.HealthcheckConfig{TCP: &apispec.HealthcheckTCPConfig{}, Interval: apispec.MustParseDuration("10ms"), FailureThreshold: 1},
+				}
+			},
+		},
+		{
+			name: "startupが失敗した場合、サービスを停止してエラーになる",
+			probes: func(unused int) *apispec.ProbesConfig {
+				return &apispec.ProbesConfig{
+					Startup: &apispec.HealthcheckConfig{TCP: &apispec.HealthcheckTCPConfig{Port: unused}, Interval: apispec.MustParseDuration("10ms"), FailureThreshold: 1},
+				}
+			},
+			wantErr: "startup check",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &apispec.AppConfig{
+				AppName:  "myapp",
+				Build:    apispec.BuildConfig{Image: "myapp:latest"},
+				Releases: []apispec.ReleaseConfig{},
+				Service: apispec.ServiceConfig{
+					Name:    "web",
+					Command: helperCommand("slow-serve", "300ms"),
+					HTTP:    []apispec.ServiceHTTPConfig{{TargetPort: freePort(t)}},
+					Probes:  tt.probes(freePort(t)),
+				},
+			}
+			var out syncBuffer
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := New(cfg, Options{Env: helperEnv(), Stdout: &out, Stderr: &out})
+
+			errc := make(chan error, 1)
+			go func() { errc <- r.Run(ctx) }()
+			if tt.wantErr != "" {
+				if err := <-errc; err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			waitFor(t, &out, "service web is ready")
+			cancel()
+			if err := <-errc; err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSupervisor_Restart(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		policy      RestartPolicy
+		wantRestart bool
+	}{
+		{name: "on-failureの場合、異常終了すると再起動する", policy: RestartOnFailure, wantRestart: true},
+		{name: "neverの場合、異常終了しても再起動しない", policy: RestartNever},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out syncBuffer
+			s := &Supervisor{
+				Process: Process{
+					Name:    "web",
+					Command: helperCommand("flaky", filepath.Join(t.TempDir(), "crashed")),
+					Env:     append(helperEnv(), fmt.Sprintf("PORT=%d", freePort(t))),
+					Stdout:  &out,
+					Stderr:  &out,
+				},
+				Policy: tt.policy,
+				Logf: func(format string, args ...any) {
+					fmt.Fprintf(&out, format+"\n", args...)
+				},
+			}
+			if err := s.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			if !tt.wantRestart {
+				<-s.Done()
+				var exitErr interface{ ExitCode() int }
+				if !errors.As(s.Err(), &exitErr) || exitErr.ExitCode() != 1 {
+					t.Errorf("Err() = %v, want exit status 1", s.Err())
+				}
+				return
+			}
+			waitFor(t, &out, "listening")
+			if !strings.Contains(out.String(), "restarting") {
+				t.Errorf("output does not report the restart:\n%s", out.String())
+			}
+			if err := s.Stop(5 * time.Second); err != nil {
+				t.Errorf("Stop() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSupervisor_StopTimeout(t *testing.T) {
+	t.Parallel()
+	var out syncBuffer
+	s := &Supervisor{
+		Process: Process{Name: "stubborn", Command: helperCommand("ignore-term"), Env: helperEnv(), Stdout: &out, Stderr: &out},
+		Policy:  RestartAlways,
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitFor(t, &out, "ignoring")
+	if err := s.Stop(100 * time.Millisecond); !errors.Is(err, ErrStopTimeout) {
+		t.Errorf("Stop() error = %v, want ErrStopTimeout", err)
+	}
+}
+
+func TestSupervisor_StopBeforeStart(t *testing.T) {
+	t.Parallel()
+	s := &Supervisor{Process: Process{Name: "web", Command: helperCommand("ignore-term"), Env: helperEnv()}}
+	if err := s.Stop(time.Second); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	// Stop の後の Start ではプロセスを起動せず、すぐに監視を終了する
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done() was not closed")
+	}
+	if err := s.Stop(time.Second); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	mux := &logMux{width: 6}
+	w := mux.writer(&out, "web")
+	fmt.Fprint(w, "hello\nwor")
+	fmt.Fprint(w, "ld\npartial")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "web    | hello\nweb    | world\nweb    | partial\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,253 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RestartPolicy はプロセスが終了した場合に再起動するかどうかを表す
+type RestartPolicy string
+
+const (
+	// RestartNever はプロセスを再起動しない
+	RestartNever RestartPolicy = "never"
+	// RestartOnFailure はプロセスが0以外の終了コードで終了した場合に再起動する
+	RestartOnFailure RestartPolicy = "on-failure"
+	// RestartAlways はプロセスが終了した場合、終了コードに関わらず再起動する
+	RestartAlways RestartPolicy = "always"
+)
+
+// ParseRestartPolicy は s を RestartPolicy として解析する
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	switch p := RestartPolicy(s); p {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown restart policy %q: must be one of never, on-failure, always", s)
+	}
+}
+
+// Process は監視するプロセスを表す
+type Process struct {
+	// Name はログなどに使うプロセスの名前
+	Name string
+	// Command は実行するコマンド
+	Command []string
+	// Dir はコマンドを実行するディレクトリ
+	Dir string
+	// Env はコマンドに渡す環境変数 (`KEY=value` 形式)
+	Env []string
+	// Stdout と Stderr はプロセスの出力先
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Supervisor はプロセスを起動し、RestartPolicy に従って再起動する
+// 最初の Start の後はフィールドを変更してはならない
+type Supervisor struct {
+	// Process は監視するプロセス
+	Process Process
+	// Policy はプロセスが終了した場合の再起動の方針
+	// 空の場合は RestartNever
+	Policy RestartPolicy
+	// MaxRestarts は再起動の回数の上限
+	// 0 の場合は上限なし
+	MaxRestarts int
+	// RestartDelay は再起動までの待ち時間
+	RestartDelay time.Duration
+	// Logf は再起動などの状態の変化を記録する
+	// nil の場合は記録しない
+	Logf func(format string, args ...any)
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	stopping  bool
+	restartRq bool
+	stopc     chan struct{}
+	done      chan struct{}
+	err       error
+}
+
+// ErrStopTimeout はプロセスが制限時間内に終了せず、強制終了したことを表す
+var ErrStopTimeout = errors.New("process did not exit in time and was killed")
+
+// Start はプロセスを起動し、終了を監視し始める
+// 最初の起動に失敗した場合はエラーを返す
+func (s *Supervisor) Start() error {
+	if len(s.Process.Command) == 0 {
+		return fmt.Errorf("%s: no command", s.Process.Name)
+	}
+	s.mu.Lock()
+	s.stopc = make(chan struct{})
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+	cmd, err := s.start()
+	if err != nil {
+		close(s.done)
+		return err
+	}
+	go s.loop(cmd)
+	return nil
+}
+
+// Done は監視が終了すると閉じられるチャネルを返す
+// 監視は Stop が呼ばれた場合、または RestartPolicy に従って再起動しなかった場合に終了する
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err は監視が終了した理由を返す
+// Stop によって終了した場合、またはプロセスが正常に終了した場合は nil
+// Done が閉じられる前に呼んではならない
+func (s *Supervisor) Err() error {
+	return s.err
+}
+
+// Signal は実行中のプロセスに sig を送信する
+func (s *Supervisor) Signal(sig os.Signal) error {
+	s.mu.Lock()
+	cmd := s.cmd
+	s.mu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	if err := cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+// Restart は実行中のプロセスに SIGTERM を送信し、終了後に RestartPolicy に関わらず再起動する
+func (s *Supervisor) Restart() error {
+	s.mu.Lock()
+	s.restartRq = true
+	s.mu.Unlock()
+	return s.Signal(syscall.SIGTERM)
+}
+
+// Stop はプロセスに SIGTERM を送信し、終了を待つ
+// timeout を過ぎても終了しない場合は強制終了し、ErrStopTimeout を返す
+// Start の前に呼んだ場合は何も待たずに nil を返し、その後の Start ではプロセスを起動しない
+func (s *Supervisor) Stop(timeout time.Duration) error {
+	s.mu.Lock()
+	if s.done == nil {
+		s.stopping = true
+		s.mu.Unlock()
+		return nil
+	}
+	if !s.stopping {
+		s.stopping = true
+		close(s.stopc)
+	}
+	cmd, done := s.cmd, s.done
+	s.mu.Unlock()
+
+	if err := s.Signal(syscall.SIGTERM); err != nil && cmd != nil {
+		// SIGTERM を送信できない環境ではすぐに強制終了する
+		_ = cmd.Process.Kill()
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return s.err
+	case <-t.C:
+		if cmd != nil {
+			_ = cmd.Process.Kill()
+		}
+		<-done
+		return fmt.Errorf("%s: %w", s.Process.Name, ErrStopTimeout)
+	}
+}
+
+// start は新しいプロセスを起動する
+// Stop が呼ばれた後は起動しない
+func (s *Supervisor) start() (*exec.Cmd, error) {
+	p := s.Process
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Env = p.Env
+	cmd.Stdout = p.Stdout
+	cmd.Stderr = p.Stderr
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return nil, nil
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	s.cmd = cmd
+	return cmd, nil
+}
+
+func (s *Supervisor) loop(cmd *exec.Cmd) {
+	defer close(s.done)
+	restarts := 0
+	for cmd != nil {
+		err := cmd.Wait()
+
+		s.mu.Lock()
+		stopping, forced := s.stopping, s.restartRq
+		s.restartRq = false
+		s.mu.Unlock()
+		if stopping {
+			return
+		}
+		if !forced {
+			if !s.shouldRestart(err) || s.MaxRestarts > 0 && restarts >= s.MaxRestarts {
+				if err != nil {
+					s.err = fmt.Errorf("%s: %w", s.Process.Name, err)
+				}
+				return
+			}
+			restarts++
+			s.logf("%s exited (%s), restarting in %s (%d)", s.Process.Name, exitReason(err), s.RestartDelay, restarts)
+			select {
+			case <-time.After(s.RestartDelay):
+			case <-s.stopc:
+				return
+			}
+		} else {
+			s.logf("%s exited (%s), restarting", s.Process.Name, exitReason(err))
+		}
+
+		cmd, err = s.start()
+		if err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+func (s *Supervisor) shouldRestart(err error) bool {
+	switch s.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+func (s *Supervisor) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+// exitReason はプロセスの終了理由を表す文字列を返す
+func exitReason(err error) string {
+	if err == nil {
+		return "exit status 0"
+	}
+	return err.Error()
+}
@@ -0,0 +1,49 @@
+package apispec
+
+import (
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ServiceMetricConfig.Type に指定できるメトリクスの種類
+const (
+	// MetricTypeCPU はCPU使用率 (%) を表す
+	MetricTypeCPU = "cpu"
+	// MetricTypeMemory はメモリ使用率 (%) を表す
+	MetricTypeMemory = "memory"
+	// MetricTypeRPS は1インスタンスあたりの秒間リクエスト数を表す
+	MetricTypeRPS = "rps"
+	// MetricTypeConcurrency は1インスタンスあたりの同時リクエスト数を表す
+	MetricTypeConcurrency = "concurrency"
+)
+
+// metricThresholdRange はメトリクスの種類ごとの閾値の範囲を表す
+// Max が0の場合は上限なし
+type metricThresholdRange struct {
+	Min int
+	Max int
+}
+
+var metricThresholdRanges = map[string]metricThresholdRange{
+	MetricTypeCPU:         {Min: 1, Max: 100},
+	MetricTypeMemory:      {Min: 1, Max: 100},
+	MetricTypeRPS:         {Min: 1},
+	MetricTypeConcurrency: {Min: 1},
+}
+
+// validateServiceMetricConfig はメトリクスの種類に応じて閾値の範囲を検証する
+// 未知の種類は Type の `oneof` ルールで報告されるため、ここでは扱わない
+func validateServiceMetricConfig(sl validator.StructLevel) {
+	m := sl.Current().Interface().(ServiceMetricConfig)
+	r, ok := metricThresholdRanges[m.Type]
+	if !ok || m.Threshold == 0 {
+		return
+	}
+	switch {
+	case m.Threshold < r.Min:
+		sl.ReportError(m.Threshold, "threshold", "Threshold", "min", strconv.Itoa(r.Min))
+	case r.Max > 0 && m.Threshold > r.Max:
+		sl.ReportError(m.Threshold, "threshold", "Threshold", "max", strconv.Itoa(r.Max))
+	}
+}
@@ -0,0 +1,275 @@
+package apispec
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run ./internal/cmd/gendesc -o descriptions_gen.go
+//go:generate go run ./internal/cmd/genschema -o schema/appconfig.schema.json
+//go:generate go run ./internal/cmd/genschema -openapi -o schema/openapi.yaml
+
+// JSONSchemaDialect は JSONSchema が出力するスキーマのバージョン
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema は AppConfig の設定ファイルを表す JSON Schema (2020-12) を返す
+// スキーマは構造体の json タグと validate タグから生成され、フィールドのコメントが description になる
+func JSONSchema() ([]byte, error) {
+	b := newSchemaBuilder("#/$defs/")
+	ref := b.ref(reflect.TypeFor[AppConfig]())
+	doc := map[string]any{
+		"$schema": JSONSchemaDialect,
+		"title":   "AppConfig",
+		"$ref":    ref["$ref"],
+		"$defs":   b.defs,
+	}
+	return marshalSchema(doc)
+}
+
+func marshalSchema(doc map[string]any) ([]byte, error) {
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// schemaProvider は独自の表現を持つ型のスキーマを提供する
+type schemaProvider interface {
+	jsonSchema() map[string]any
+}
+
+var schemaProviderType = reflect.TypeFor[schemaProvider]()
+
+// schemaBuilder は Go の型から JSON Schema を組み立てる
+// 名前付きの構造体と schemaProvider を実装する型は定義として refPrefix 以下に出力される
+type schemaBuilder struct {
+	refPrefix string
+	defs      map[string]any
+}
+
+func newSchemaBuilder(refPrefix string) *schemaBuilder {
+	return &schemaBuilder{refPrefix: refPrefix, defs: map[string]any{}}
+}
+
+// ref は t の定義を登録し、定義への参照を返す
+func (b *schemaBuilder) ref(t reflect.Type) map[string]any {
+	name := t.Name()
+	if _, ok := b.defs[name]; !ok {
+		// 再帰的な型に備えて先に登録する
+		b.defs[name] = nil
+		var def map[string]any
+		if t.Implements(schemaProviderType) {
+			def = reflect.Zero(t).Interface().(schemaProvider).jsonSchema()
+		} else {
+			def = b.object(t)
+		}
+		if desc := typeDescriptions[name]; desc != "" {
+			def["description"] = desc
+		}
+		b.defs[name] = def
+	}
+	return map[string]any{"$ref": b.refPrefix + name}
+}
+
+// schema は t のスキーマを返す
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	t = indirectType(t)
+	if t.Implements(schemaProviderType) || t.Kind() == reflect.Struct {
+		return b.ref(t)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object は構造体 t のスキーマを返す
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	var constraints []any
+	for _, f := range structFields(t) {
+		prop := b.schema(f.Field.Type)
+		if desc := fieldDescriptions[t.Name()+"."+f.Field.Name]; desc != "" {
+			prop["description"] = desc
+		}
+		for _, r := range fieldRules(f.Field) {
+			switch r.tag {
+			case "required":
+				if !slices.Contains(required, f.Name) {
+					required = append(required, f.Name)
+				}
+			case "min":
+				applyBound(prop, f.Field.Type, r.param, "minimum", "minLength", "minItems")
+			case "max":
+				applyBound(prop, f.Field.Type, r.param, "maximum", "maxLength", "maxItems")
+			case "oneof":
+				enum := []any{}
+				for _, v := range strings.Fields(r.param) {
+					enum = append(enum, v)
+				}
+				prop["enum"] = enum
+			case "positive_duration":
+				prop["pattern"] = positiveDurationPattern.String()
+			case "version":
+				prop["enum"] = []any{LatestVersion}
+			case "required_if":
+				// `required_if=Field value` は Field が value の場合に必須であることを表す
+				other, value, _ := strings.Cut(r.param, " ")
+				constraints = append(constraints, map[string]any{
+					"if": map[string]any{
+						"properties": map[string]any{jsonFieldName(t, other): map[string]any{"const": value}},
+						"required":   []string{jsonFieldName(t, other)},
+					},
+					"then": map[string]any{"required": []string{f.Name}},
+				})
+			case "required_without":
+				constraints = append(constraints, map[string]any{
+					"anyOf": []any{
+						map[string]any{"required": []string{f.Name}},
+						map[string]any{"required": []string{jsonFieldName(t, r.param)}},
+					},
+				})
+			case "required_without_all":
+				anyOf := []any{map[string]any{"required": []string{f.Name}}}
+				for _, other := range strings.Fields(r.param) {
+					anyOf = append(anyOf, map[string]any{"required": []string{jsonFieldName(t, other)}})
+				}
+				constraints = append(constraints, map[string]any{"anyOf": anyOf})
+			case "excluded_with":
+				for _, other := range strings.Fields(r.param) {
+					constraints = append(constraints, map[string]any{
+						"not": map[string]any{"required": []string{f.Name, jsonFieldName(t, other)}},
+					})
+				}
+			}
+		}
+		if slices.Contains(keyRules(f.Field), "envname") {
+			prop["propertyNames"] = map[string]any{"pattern": envNamePattern.String()}
+		}
+		props[f.Name] = prop
+	}
+	obj := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	if extra, ok := schemaConstraints[t]; ok {
+		constraints = append(constraints, extra()...)
+	}
+	if len(constraints) > 0 {
+		obj["allOf"] = constraints
+	}
+	return obj
+}
+
+// schemaConstraints は validate タグでは表現できない構造体単位の制約をスキーマに追加する
+var schemaConstraints = map[reflect.Type]func() []any{
+	// 空の build_args は image と同時に指定できる (validateBuildConfig を参照)
+	reflect.TypeFor[BuildConfig](): func() []any {
+		return []any{map[string]any{
+			"not": map[string]any{
+				"required":   []string{"build_args", "image"},
+				"properties": map[string]any{"build_args": map[string]any{"minProperties": 1}},
+			},
+		}}
+	},
+	reflect.TypeFor[ServiceMetricConfig](): func() []any {
+		var constraints []any
+		for _, typ := range []string{MetricTypeCPU, MetricTypeMemory, MetricTypeRPS, MetricTypeConcurrency} {
+			r := metricThresholdRanges[typ]
+			threshold := map[string]any{"minimum": r.Min}
+			if r.Max > 0 {
+				threshold["maximum"] = r.Max
+			}
+			constraints = append(constraints, map[string]any{
+				"if": map[string]any{
+					"properties": map[string]any{"type": map[string]any{"const": typ}},
+					"required":   []string{"type"},
+				},
+				"then": map[string]any{"properties": map[string]any{"threshold": threshold}},
+			})
+		}
+		return constraints
+	},
+}
+
+// applyBound は `min` または `max` ルールを型に応じたキーワードとしてスキーマに設定する
+func applyBound(prop map[string]any, t reflect.Type, param, numberKey, stringKey, arrayKey string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	switch indirectType(t).Kind() {
+	case reflect.String:
+		prop[stringKey] = n
+	case reflect.Slice, reflect.Array, reflect.Map:
+		prop[arrayKey] = n
+	default:
+		prop[numberKey] = n
+	}
+}
+
+// validateRule は validate タグの1つのルールを表す
+type validateRule struct {
+	tag   string
+	param string
+}
+
+// fieldRules は f の validate タグのうち、フィールド自身に適用されるルールを返す
+// `dive` 以降のルールは要素に適用されるため含まない
+func fieldRules(f reflect.StructField) []validateRule {
+	tag := f.Tag.Get("validate")
+	if tag == "" || tag == "-" {
+		return nil
+	}
+	var rules []validateRule
+	for _, r := range strings.Split(tag, ",") {
+		if r == "dive" {
+			break
+		}
+		name, param, _ := strings.Cut(r, "=")
+		rules = append(rules, validateRule{tag: name, param: param})
+	}
+	return rules
+}
+
+// keyRules は f の validate タグのうち、`keys` と `endkeys` の間にあるマップのキーに適用されるルールの名前を返す
+func keyRules(f reflect.StructField) []string {
+	_, rest, ok := strings.Cut(f.Tag.Get("validate"), ",keys,")
+	if !ok {
+		return nil
+	}
+	rest, _, _ = strings.Cut(rest, ",endkeys")
+	return strings.Split(rest, ",")
+}
+
+// jsonFieldName は構造体 t の Go のフィールド名に対応する設定ファイル上のキー名を返す
+func jsonFieldName(t reflect.Type, goName string) string {
+	for _, f := range structFields(t) {
+		if f.Field.Name == goName {
+			return f.Name
+		}
+	}
+	return goName
+}
@@ -0,0 +1,219 @@
+package apispec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// compileSchema は JSONSchema の出力をコンパイルする
+func compileSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	b, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("appconfig.schema.json", doc); err != nil {
+		t.Fatalf("AddResource() error = %v", err)
+	}
+	s, err := c.Compile("appconfig.schema.json")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return s
+}
+
+// yamlToJSONValue は YAML を JSON Schema の検証に使える値に変換する
+func yamlToJSONValue(t *testing.T, src string) any {
+	t.Helper()
+	var v any
+	if err := yaml.Unmarshal([]byte(src), &v); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	return inst
+}
+
+func TestJSONSchema_Validate(t *testing.T) {
+	t.Parallel()
+	schema := compileSchema(t)
+	valid, err := os.ReadFile("testdata/valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	processes, err := os.ReadFile("testdata/processes.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "正しい設定ファイルの場合、エラーにならない",
+			input: string(valid),
+		},
+		{
+			name:  "ワーカーとジョブを含む設定ファイルの場合、エラーにならない",
+			input: string(processes),
+		},
+		{
+			name:    "ジョブのタイムアウトの形式が不正な場合、エラーになる",
+			input:   strings.Replace(string(processes), "timeout: 30m", "timeout: 30", 1),
+			wantErr: true,
+		},
+		{
+			name: "必須フィールドが欠けている場合、エラーになる",
+			input: `
+app_name: myapp
+build: {image: myapp:latest}
+releases: []
+`,
+			wantErr: true,
+		},
+		{
+			name:    "未知のキーがある場合、エラーになる",
+			input:   strings.Replace(string(valid), "target_port", "target_prot", 1),
+			wantErr: true,
+		},
+		{
+			name:    "ImageとDockerfileがともに設定されている場合、エラーになる",
+			input:   strings.Replace(string(valid), "image: myapp:latest", "{image: myapp:latest, dockerfile: Dockerfile}", 1),
+			wantErr: true,
+		},
+		{
+			name:  "Imageと空のbuild_argsが設定されている場合、エラーにならない",
+			input: strings.Replace(string(valid), "image: myapp:latest", "{image: myapp:latest, build_args: {}}", 1),
+		},
+		{
+			name:    "Imageとbuild_argsがともに設定されている場合、エラーになる",
+			input:   strings.Replace(string(valid), "image: myapp:latest", "{image: myapp:latest, build_args: {A: b}}", 1),
+			wantErr: true,
+		},
+		{
+			name:    "リソース量の形式が不正な場合、エラーになる",
+			input:   strings.Replace(string(valid), "256Mi", "256MB", 1),
+			wantErr: true,
+		},
+		{
+			name: "ポリシーがbranchでブランチが設定されていない場合、エラーになる",
+			input: string(valid) + `stages:
+  - name: production
+    policy: {type: branch}
+`,
+			wantErr: true,
+		},
+		{
+			name: "ヘルスチェックの種類が複数設定されている場合、エラーになる",
+			input: string(valid) + `  healthcheck:
+    http: {path: /healthz}
+    tcp: {port: 8080}
+`,
+			wantErr: true,
+		},
+		{
+			name: "ヘルスチェックのタイミングが設定されている場合、エラーにならない",
+			input: string(valid) + `  probes:
+    liveness:
+      tcp: {}
+      interval: 15s
+      failure_threshold: 5
+`,
+		},
+		{
+			name: "ヘルスチェックの間隔が0の場合、エラーになる",
+			input: string(valid) + `  probes:
+    liveness:
+      tcp: {}
+      interval: 0s
+`,
+			wantErr: true,
+		},
+		{
+			name: "ヘルスチェックの制限時間が0の場合、エラーになる",
+			input: string(valid) + `  probes:
+    liveness:
+      tcp: {}
+      timeout: "0"
+`,
+			wantErr: true,
+		},
+		{
+			name: "CPU使用率の閾値が100を超える場合、エラーになる",
+			input: string(valid) + `  scale:
+    min: 0
+    max: 3
+    metric: {type: cpu, threshold: 150}
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := schema.Validate(yamlToJSONValue(t, tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJSONSchema_Descriptions(t *testing.T) {
+	t.Parallel()
+	b, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	var doc struct {
+		Defs map[string]struct {
+			Description string `json:"description"`
+			Properties  map[string]struct {
+				Description string `json:"description"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got, want := doc.Defs["AppConfig"].Properties["app_name"].Description, "AppName はアプリケーションの名前"; got != want {
+		t.Errorf("app_name description = %q, want %q", got, want)
+	}
+	if got, want := doc.Defs["ServiceConfig"].Description, "ServiceConfig はアプリケーションのサービス設定を表す"; got != want {
+		t.Errorf("ServiceConfig description = %q, want %q", got, want)
+	}
+}
+
+func TestJSONSchema_UpToDate(t *testing.T) {
+	t.Parallel()
+	want, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+	got, err := os.ReadFile("schema/appconfig.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("schema/appconfig.schema.json is out of date, run `go generate ./...`")
+	}
+}
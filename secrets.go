@@ -0,0 +1,52 @@
+package apispec
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileSecretStore はローカルのファイルに保存されたシークレットを表す
+// 開発環境などで SecretResolver として使うことを想定している
+//
+// ファイルはシークレットの名前ごとにキーと値を持つ YAML または JSON で記述する
+//
+//	database:
+//	  password: s3cr3t
+//	  user: app
+type FileSecretStore struct {
+	secrets map[string]map[string]string
+}
+
+var _ SecretResolver = (*FileSecretStore)(nil)
+
+// NewFileSecretStore は secrets を保持する FileSecretStore を作成する
+func NewFileSecretStore(secrets map[string]map[string]string) *FileSecretStore {
+	return &FileSecretStore{secrets: secrets}
+}
+
+// LoadFileSecretStore は path のファイルからシークレットを読み込む
+func LoadFileSecretStore(path string) (*FileSecretStore, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var secrets map[string]map[string]string
+	// JSON は YAML のサブセットとして読み込める
+	if err := yaml.Unmarshal(b, &secrets); err != nil {
+		return nil, &LoadError{File: path, Err: err}
+	}
+	return NewFileSecretStore(secrets), nil
+}
+
+// ResolveSecret は name のシークレットの key の値を返す
+// シークレットまたはキーが存在しない場合は ErrSecretNotFound を返す
+func (s *FileSecretStore) ResolveSecret(_ context.Context, name, key string) (string, error) {
+	v, ok := s.secrets[name][key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s/%s", ErrSecretNotFound, name, key)
+	}
+	return v, nil
+}
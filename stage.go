@@ -0,0 +1,294 @@
+package apispec
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// StagePolicyConfig.Type に指定できるポリシーの種類
+const (
+	// StagePolicyTypeBranch はブランチへのプッシュでデプロイするポリシー
+	StagePolicyTypeBranch = "branch"
+	// StagePolicyTypeTag はタグのプッシュでデプロイするポリシー
+	StagePolicyTypeTag = "tag"
+	// StagePolicyTypePullRequest はプルリクエストごとにプレビュー環境へデプロイするポリシー
+	StagePolicyTypePullRequest = "pull_request"
+	// StagePolicyTypeManual は手動で昇格させた場合にのみデプロイするポリシー
+	// git の参照には一致しない
+	StagePolicyTypeManual = "manual"
+)
+
+// RefKind は git の参照の種類を表す
+type RefKind int
+
+const (
+	// RefBranch はブランチを表す
+	RefBranch RefKind = iota
+	// RefTag はタグを表す
+	RefTag
+	// RefPullRequest はプルリクエストを表す
+	RefPullRequest
+)
+
+// GitRef は正規化した git の参照を表す
+type GitRef struct {
+	// Kind は参照の種類
+	Kind RefKind
+	// Name はブランチ名、タグ名、またはプルリクエストの番号
+	Name string
+}
+
+// ParseRef は git の参照を正規化する
+//
+//   - `refs/heads/<name>` はブランチ
+//   - `refs/tags/<name>` はタグ
+//   - `refs/pull/<number>/head` と `refs/pull/<number>/merge` はプルリクエスト
+//   - それ以外はブランチ名とみなす
+func ParseRef(ref string) GitRef {
+	if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+		return GitRef{Kind: RefBranch, Name: name}
+	}
+	if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		return GitRef{Kind: RefTag, Name: name}
+	}
+	if rest, ok := strings.CutPrefix(ref, "refs/pull/"); ok {
+		number, _, _ := strings.Cut(rest, "/")
+		return GitRef{Kind: RefPullRequest, Name: number}
+	}
+	return GitRef{Kind: RefBranch, Name: ref}
+}
+
+// Matches は ref がこのポリシーの対象かどうかを返す
+// ref は ParseRef と同様に正規化される
+func (p StagePolicyConfig) Matches(ref string) bool {
+	r := ParseRef(ref)
+	switch p.Type {
+	case StagePolicyTypeBranch:
+		return r.Kind == RefBranch && p.Branch != nil && matchRefPattern(p.Branch.Name, r.Name)
+	case StagePolicyTypeTag:
+		if r.Kind != RefTag || p.Tag == nil || !matchRefPattern(p.Tag.Pattern, r.Name) {
+			return false
+		}
+		return !p.Tag.Semver || semverPattern.MatchString(r.Name)
+	case StagePolicyTypePullRequest:
+		return r.Kind == RefPullRequest
+	default:
+		return false
+	}
+}
+
+// AmbiguousRefError は git の参照が複数のステージに一致したことを表す
+type AmbiguousRefError struct {
+	// Ref は評価した参照
+	Ref string
+	// Stages は一致したステージの名前
+	Stages []string
+}
+
+func (e *AmbiguousRefError) Error() string {
+	return fmt.Sprintf("ref %q matches multiple stages: %s", e.Ref, strings.Join(e.Stages, ", "))
+}
+
+// StagesForRef は ref のプッシュでデプロイされるステージを返す
+// ステージが定義されていない場合は ApplyDefaults と同様にデフォルトのステージを評価する
+// 一致するステージがない場合は空のスライスを返す
+//
+// ブランチ名やタグ名を完全一致で指定したステージは、パターンで指定したステージより優先される
+// それでも複数のステージが一致する場合は、一致したステージとともに *AmbiguousRefError を返す
+func (c *AppConfig) StagesForRef(ref string) ([]StageConfig, error) {
+	cfg := AppConfig{Stages: slices.Clone(c.Stages)}
+	cfg.ApplyDefaults()
+
+	var matches []StageConfig
+	for _, s := range cfg.Stages {
+		if s.Policy.Matches(ref) {
+			matches = append(matches, s)
+		}
+	}
+	if slices.ContainsFunc(matches, StageConfig.isLiteral) {
+		matches = slices.DeleteFunc(matches, func(s StageConfig) bool { return !s.isLiteral() })
+	}
+	if len(matches) > 1 {
+		names := make([]string, len(matches))
+		for i, s := range matches {
+			names[i] = s.Name
+		}
+		return matches, &AmbiguousRefError{Ref: ref, Stages: names}
+	}
+	return matches, nil
+}
+
+// isLiteral はステージのポリシーがブランチ名やタグ名を完全一致で指定しているかどうかを返す
+func (s StageConfig) isLiteral() bool {
+	switch {
+	case s.Policy.Type == StagePolicyTypeBranch && s.Policy.Branch != nil:
+		return isLiteralPattern(s.Policy.Branch.Name)
+	case s.Policy.Type == StagePolicyTypeTag && s.Policy.Tag != nil:
+		return isLiteralPattern(s.Policy.Tag.Pattern)
+	default:
+		return false
+	}
+}
+
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// isRegexpPattern は pattern がスラッシュで囲まれた正規表現かどうかを返す
+// git の参照名はスラッシュで始まらないため、ブランチ名と区別できる
+func isRegexpPattern(pattern string) bool {
+	return len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")
+}
+
+// isLiteralPattern は pattern がワイルドカードを含まない名前かどうかを返す
+func isLiteralPattern(pattern string) bool {
+	return !isRegexpPattern(pattern) && !strings.ContainsAny(pattern, "*?")
+}
+
+// compileRefPattern はブランチ名やタグ名のパターンを正規表現に変換する
+//
+//   - `/.../` は正規表現として扱い、部分一致で評価する
+//   - `*` は `/` 以外の任意の文字列、`**` は `/` を含む任意の文字列、`?` は `/` 以外の任意の1文字に一致する
+//   - それ以外の文字は名前全体との完全一致で評価する
+func compileRefPattern(pattern string) (*regexp.Regexp, error) {
+	if isRegexpPattern(pattern) {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+	var b strings.Builder
+	b.WriteByte('^')
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteByte('$')
+	return regexp.Compile(b.String())
+}
+
+// globToken はワイルドカードのパターンの1文字分の要素を表す
+type globToken struct {
+	// r は文字 (literal が true の場合のみ有効)
+	r rune
+	// literal は r との完全一致かどうか
+	literal bool
+	// slash は `/` にも一致するかどうか
+	slash bool
+	// repeat は0文字以上の繰り返しかどうか
+	repeat bool
+}
+
+// globTokens は pattern を compileRefPattern と同じ規則で要素に分解する
+func globTokens(pattern string) []globToken {
+	runes := []rune(pattern)
+	var tokens []globToken
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				tokens = append(tokens, globToken{slash: true, repeat: true})
+				i++
+			} else {
+				tokens = append(tokens, globToken{repeat: true})
+			}
+		case '?':
+			tokens = append(tokens, globToken{})
+		default:
+			tokens = append(tokens, globToken{r: runes[i], literal: true})
+		}
+	}
+	return tokens
+}
+
+// overlaps は a と b の両方に一致する文字が存在するかどうかを返す
+func (a globToken) overlaps(b globToken) bool {
+	switch {
+	case a.literal && b.literal:
+		return a.r == b.r
+	case a.literal:
+		return a.r != '/' || b.slash
+	case b.literal:
+		return b.r != '/' || a.slash
+	default:
+		return true
+	}
+}
+
+// refPatternsOverlap はワイルドカードのパターン a と b の両方に一致する名前が存在するかどうかを返す
+// 正規表現のパターンは判定できないため false を返す
+func refPatternsOverlap(a, b string) bool {
+	if isRegexpPattern(a) || isRegexpPattern(b) {
+		return false
+	}
+	ta, tb := globTokens(a), globTokens(b)
+	// (a の位置, b の位置) を状態とし、両方のパターンを同時に進めて末尾に到達できるかを探索する
+	type state struct{ i, j int }
+	seen := map[state]bool{}
+	queue := []state{{0, 0}}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if s.i == len(ta) && s.j == len(tb) {
+			return true
+		}
+		// 繰り返しは0文字に一致できる
+		if s.i < len(ta) && ta[s.i].repeat {
+			queue = append(queue, state{s.i + 1, s.j})
+		}
+		if s.j < len(tb) && tb[s.j].repeat {
+			queue = append(queue, state{s.i, s.j + 1})
+		}
+		if s.i == len(ta) || s.j == len(tb) || !ta[s.i].overlaps(tb[s.j]) {
+			continue
+		}
+		// 同じ1文字を両方のパターンで消費する
+		next := s
+		if !ta[s.i].repeat {
+			next.i++
+		}
+		if !tb[s.j].repeat {
+			next.j++
+		}
+		queue = append(queue, next)
+	}
+	return false
+}
+
+// matchRefPattern は name が pattern に一致するかどうかを返す
+// 不正なパターンはどの名前にも一致しない
+func matchRefPattern(pattern, name string) bool {
+	if isLiteralPattern(pattern) {
+		return pattern == name
+	}
+	re, err := compileRefPattern(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// validateRefPattern は `refpattern` ルールを実装する
+// 値がブランチ名やタグ名のパターンとして解釈できることを検証する
+func validateRefPattern(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "" {
+		return true
+	}
+	_, err := compileRefPattern(s)
+	return err == nil
+}
@@ -0,0 +1,207 @@
+package apispec
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		ref  string
+		want GitRef
+	}{
+		{ref: "refs/heads/main", want: GitRef{Kind: RefBranch, Name: "main"}},
+		{ref: "refs/heads/release/1.2", want: GitRef{Kind: RefBranch, Name: "release/1.2"}},
+		{ref: "refs/tags/v1.2.3", want: GitRef{Kind: RefTag, Name: "v1.2.3"}},
+		{ref: "refs/pull/42/head", want: GitRef{Kind: RefPullRequest, Name: "42"}},
+		{ref: "refs/pull/42/merge", want: GitRef{Kind: RefPullRequest, Name: "42"}},
+		{ref: "develop", want: GitRef{Kind: RefBranch, Name: "develop"}},
+	}
+	for _, tt := range tests {
+		if got := ParseRef(tt.ref); got != tt.want {
+			t.Errorf("ParseRef(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestRefPatternsOverlap(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "release/*", b: "release/**", want: true},
+		{a: "release/*", b: "*/1", want: true},
+		{a: "feature/a?c", b: "feature/ab*", want: true},
+		{a: "**", b: "a/b/*", want: true},
+		{a: "release/*", b: "feature/*", want: false},
+		{a: "release/*", b: "release/*/hotfix", want: false},
+		{a: "release-?", b: "release-??", want: false},
+		{a: "release/*", b: "/^release/", want: false},
+	}
+	for _, tt := range tests {
+		for _, pair := range [][2]string{{tt.a, tt.b}, {tt.b, tt.a}} {
+			if got := refPatternsOverlap(pair[0], pair[1]); got != tt.want {
+				t.Errorf("refPatternsOverlap(%q, %q) = %v, want %v", pair[0], pair[1], got, tt.want)
+			}
+		}
+	}
+}
+
+func TestStagePolicyConfig_Matches(t *testing.T) {
+	t.Parallel()
+	branch := func(name string) StagePolicyConfig {
+		return StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: name}}
+	}
+	tag := func(pattern string, semver bool) StagePolicyConfig {
+		return StagePolicyConfig{Type: "tag", Tag: &TagConfig{Pattern: pattern, Semver: semver}}
+	}
+	tests := []struct {
+		name   string
+		policy StagePolicyConfig
+		ref    string
+		want   bool
+	}{
+		{name: "ブランチ名が一致する", policy: branch("main"), ref: "refs/heads/main", want: true},
+		{name: "正規化されていないブランチ名も一致する", policy: branch("main"), ref: "main", want: true},
+		{name: "ブランチ名が異なる", policy: branch("main"), ref: "refs/heads/develop", want: false},
+		{name: "同名のタグには一致しない", policy: branch("main"), ref: "refs/tags/main", want: false},
+		{name: "globの*は/を含まない名前に一致する", policy: branch("release/*"), ref: "refs/heads/release/1.2", want: true},
+		{name: "globの*は/を含む名前に一致しない", policy: branch("release/*"), ref: "refs/heads/release/1.2/hotfix", want: false},
+		{name: "globの**は/を含む名前に一致する", policy: branch("release/**"), ref: "refs/heads/release/1.2/hotfix", want: true},
+		{name: "globの?は1文字に一致する", policy: branch("v?"), ref: "refs/heads/v2", want: true},
+		{name: "globの.は文字として扱われる", policy: branch("release-1.2"), ref: "refs/heads/release-1x2", want: false},
+		{name: "正規表現に一致する", policy: branch(`/^feature\/.+$/`), ref: "refs/heads/feature/login", want: true},
+		{name: "正規表現に一致しない", policy: branch(`/^feature\/.+$/`), ref: "refs/heads/fix/login", want: false},
+		{name: "タグのパターンに一致する", policy: tag("v*", false), ref: "refs/tags/v1.2.3", want: true},
+		{name: "同名のブランチには一致しない", policy: tag("v*", false), ref: "refs/heads/v1.2.3", want: false},
+		{name: "semverのタグに一致する", policy: tag("v*", true), ref: "refs/tags/v1.2.3-rc.1", want: true},
+		{name: "semverでないタグに一致しない", policy: tag("v*", true), ref: "refs/tags/v1.2", want: false},
+		{name: "プルリクエストに一致する", policy: StagePolicyConfig{Type: "pull_request"}, ref: "refs/pull/42/head", want: true},
+		{name: "プルリクエストのポリシーはブランチに一致しない", policy: StagePolicyConfig{Type: "pull_request"}, ref: "refs/heads/main", want: false},
+		{name: "手動のポリシーはどの参照にも一致しない", policy: StagePolicyConfig{Type: "manual"}, ref: "refs/heads/main", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.policy.Matches(tt.ref); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStagePolicyConfig_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		config  StagePolicyConfig
+		wantErr bool
+	}{
+		{
+			name:    "branchでBranchが設定されている場合、エラーにならない",
+			config:  StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "release/*"}},
+			wantErr: false,
+		},
+		{
+			name:    "branchでBranchが設定されていない場合、エラーになる",
+			config:  StagePolicyConfig{Type: "branch"},
+			wantErr: true,
+		},
+		{
+			name:    "tagでTagが設定されている場合、エラーにならない",
+			config:  StagePolicyConfig{Type: "tag", Tag: &TagConfig{Pattern: "v*", Semver: true}},
+			wantErr: false,
+		},
+		{
+			name:    "tagでTagが設定されていない場合、エラーになる",
+			config:  StagePolicyConfig{Type: "tag"},
+			wantErr: true,
+		},
+		{
+			name:    "pull_requestの場合、エラーにならない",
+			config:  StagePolicyConfig{Type: "pull_request"},
+			wantErr: false,
+		},
+		{
+			name:    "manualの場合、エラーにならない",
+			config:  StagePolicyConfig{Type: "manual"},
+			wantErr: false,
+		},
+		{
+			name:    "未知のTypeの場合、エラーになる",
+			config:  StagePolicyConfig{Type: "schedule"},
+			wantErr: true,
+		},
+		{
+			name:    "正規表現が不正な場合、エラーになる",
+			config:  StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "/release(/"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateStruct(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("StagePolicyConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppConfig_StagesForRef(t *testing.T) {
+	t.Parallel()
+	stages := []StageConfig{
+		{Name: "production", Policy: StagePolicyConfig{Type: "tag", Tag: &TagConfig{Pattern: "v*", Semver: true}}},
+		{Name: "staging", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "main"}}},
+		{Name: "release", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "release/*"}}},
+		{Name: "hotfix", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "release/hotfix"}}},
+		{Name: "preview", Policy: StagePolicyConfig{Type: "pull_request"}},
+		{Name: "sandbox", Policy: StagePolicyConfig{Type: "manual"}},
+		{Name: "qa-a", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "qa/*"}}},
+		{Name: "qa-b", Policy: StagePolicyConfig{Type: "branch", Branch: &BranchConfig{Name: "/^qa\\//"}}},
+	}
+	tests := []struct {
+		name          string
+		stages        []StageConfig
+		ref           string
+		want          []string
+		wantAmbiguous bool
+	}{
+		{name: "ブランチに一致するステージを返す", stages: stages, ref: "refs/heads/main", want: []string{"staging"}},
+		{name: "パターンに一致するステージを返す", stages: stages, ref: "refs/heads/release/1.2", want: []string{"release"}},
+		{name: "完全一致のステージがパターンより優先される", stages: stages, ref: "refs/heads/release/hotfix", want: []string{"hotfix"}},
+		{name: "タグに一致するステージを返す", stages: stages, ref: "refs/tags/v1.2.3", want: []string{"production"}},
+		{name: "プルリクエストに一致するステージを返す", stages: stages, ref: "refs/pull/7/merge", want: []string{"preview"}},
+		{name: "一致するステージがない場合は空を返す", stages: stages, ref: "refs/heads/feature/x", want: nil},
+		{name: "複数のパターンに一致する場合はエラーになる", stages: stages, ref: "refs/heads/qa/1", want: []string{"qa-a", "qa-b"}, wantAmbiguous: true},
+		{name: "ステージが定義されていない場合はデフォルトのステージを評価する", stages: nil, ref: "refs/heads/main", want: []string{"production"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := AppConfig{Stages: tt.stages}
+			got, err := cfg.StagesForRef(tt.ref)
+			var ambiguous *AmbiguousRefError
+			if errors.As(err, &ambiguous) != tt.wantAmbiguous {
+				t.Fatalf("StagesForRef(%q) error = %v, wantAmbiguous %v", tt.ref, err, tt.wantAmbiguous)
+			}
+			names := make([]string, 0, len(got))
+			for _, s := range got {
+				names = append(names, s.Name)
+			}
+			if len(tt.want) == 0 && len(names) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("StagesForRef(%q) = %v, want %v", tt.ref, names, tt.want)
+			}
+			if ambiguous != nil && !reflect.DeepEqual(ambiguous.Stages, tt.want) {
+				t.Errorf("AmbiguousRefError.Stages = %v, want %v", ambiguous.Stages, tt.want)
+			}
+		})
+	}
+}
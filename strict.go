@@ -0,0 +1,140 @@
+package apispec
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownFieldError は設定ファイルに未知のキーが含まれていることを表す
+type UnknownFieldError struct {
+	// Path は未知のキーのフルパス (例: `service.http[0].target_prot`)
+	Path string
+	// Key は未知のキー名
+	Key string
+	// Suggestion は最も近い既知のキー名
+	// 近いキーが見つからない場合は空
+	Suggestion string
+	// Position は未知のキーの設定ファイル上の位置
+	Position Position
+}
+
+func (e *UnknownFieldError) Error() string {
+	msg := fmt.Sprintf("%s: unknown field %q", e.Path, e.Key)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", e.Suggestion)
+	}
+	if e.Position.IsValid() {
+		return e.Position.String() + ": " + msg
+	}
+	return msg
+}
+
+// UnknownFieldErrors は未知のキーのエラーの一覧を表す
+type UnknownFieldErrors []*UnknownFieldError
+
+func (e UnknownFieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// checkUnknownFields は node を t と照合し、t に存在しないキーをすべて返す
+func checkUnknownFields(node *yaml.Node, t reflect.Type) UnknownFieldErrors {
+	var errs UnknownFieldErrors
+	walkUnknownFields(node, t, "", &errs)
+	return errs
+}
+
+func walkUnknownFields(node *yaml.Node, t reflect.Type, path string, errs *UnknownFieldErrors) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	t = indirectType(t)
+	if isOpaque(t) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := structFields(t)
+		byName := make(map[string]reflect.Type, len(fields))
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			byName[f.Name] = f.Field.Type
+			names[i] = f.Name
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			ft, ok := byName[key]
+			if !ok {
+				*errs = append(*errs, &UnknownFieldError{
+					Path:       joinPath(path, key),
+					Key:        key,
+					Suggestion: suggest(key, names),
+				})
+				continue
+			}
+			walkUnknownFields(value, ft, joinPath(path, key), errs)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range node.Content {
+			walkUnknownFields(item, t.Elem(), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkUnknownFields(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), errs)
+		}
+	}
+}
+
+// suggest は candidates の中から key に最も近い名前を返す
+// 編集距離が名前の長さに対して大きすぎる場合は空を返す
+func suggest(key string, candidates []string) string {
+	best, bestDist := "", -1
+	for _, c := range candidates {
+		d := levenshtein(key, c)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if bestDist < 0 || bestDist > max(2, len(key)/3) {
+		return ""
+	}
+	return best
+}
+
+// levenshtein は a と b の編集距離を返す
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,87 @@
+package apispec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Strict(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		want  []UnknownFieldError
+	}{
+		{
+			name: "未知のキーがない場合、エラーにならない",
+			input: `
+app_name: myapp
+build: {image: myapp:latest}
+releases: [{name: r, resources: {cpu: 500m, memory: 256Mi}, action: {command: [echo]}}]
+service: {name: web, command: [npm, start], http: [{target_port: 8080}]}
+`,
+		},
+		{
+			name: "綴りを誤ったキーはフルパスと候補とともに報告される",
+			input: `
+app_name: myapp
+build: {image: myapp:latest, docker_contxt: .}
+releases: [{name: r, resources: {cpu: 500m, memory: 256Mi}, action: {command: [echo]}}]
+service: {name: web, command: [npm, start], http: [{target_port: 8080}, {target_prot: 8081}]}
+`,
+			want: []UnknownFieldError{
+				{Path: "build.docker_contxt", Key: "docker_contxt", Suggestion: "docker_context"},
+				{Path: "service.http[1].target_prot", Key: "target_prot", Suggestion: "target_port"},
+			},
+		},
+		{
+			name: "近いキーがない場合、候補は空になる",
+			input: `
+app_name: myapp
+build: {image: myapp:latest}
+releases: [{name: r, resources: {cpu: 500m, memory: 256Mi}, action: {command: [echo]}}]
+service: {name: web, command: [npm, start]}
+something_else: true
+`,
+			want: []UnknownFieldError{
+				{Path: "something_else", Key: "something_else"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Load(strings.NewReader(tt.input), FormatYAML, WithStrict())
+			var got UnknownFieldErrors
+			errors.As(err, &got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Load() unknown fields = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				got := *got[i]
+				got.Position = Position{}
+				if got != tt.want[i] {
+					t.Errorf("unknown field[%d] = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+			if len(tt.want) == 0 && err != nil {
+				t.Errorf("Load() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestLoad_NotStrict(t *testing.T) {
+	t.Parallel()
+	input := `
+app_name: myapp
+build: {image: myapp:latest, docker_contxt: .}
+releases: [{name: r, resources: {cpu: 500m, memory: 256Mi}, action: {command: [echo]}}]
+service: {name: web, command: [npm, start]}
+`
+	if _, err := Load(strings.NewReader(input), FormatYAML); err != nil {
+		t.Errorf("Load() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,317 @@
+package apispec
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ja"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+)
+
+// Locale はバリデーションメッセージの言語を表す
+type Locale string
+
+const (
+	// LocaleJapanese は日本語を表す
+	LocaleJapanese Locale = "ja"
+	// LocaleEnglish は英語を表す
+	LocaleEnglish Locale = "en"
+)
+
+// DefaultLocale は ValidationError.Message に使われる言語
+const DefaultLocale = LocaleJapanese
+
+// locales はメッセージを生成する言語の一覧
+var locales = []Locale{LocaleJapanese, LocaleEnglish}
+
+// messages は validator の既定の翻訳を上書き、または補完するメッセージを表す
+// {0} はフィールド名、{1} はルールのパラメータに置き換えられる
+var messages = map[string]map[Locale]string{
+	"required_without": {
+		LocaleJapanese: "{0}は{1}が指定されていない場合に必須です",
+		LocaleEnglish:  "{0} is required when {1} is not set",
+	},
+	"required_without_all": {
+		LocaleJapanese: "{0}は{1}のいずれも指定されていない場合に必須です",
+		LocaleEnglish:  "{0} is required when none of {1} is set",
+	},
+	"excluded_with": {
+		LocaleJapanese: "{0}は{1}と同時に指定できません",
+		LocaleEnglish:  "{0} cannot be set together with {1}",
+	},
+	"gtefield": {
+		LocaleJapanese: "{0}は{1}以上でなければなりません",
+		LocaleEnglish:  "{0} must be greater than or equal to {1}",
+	},
+	"refpattern": {
+		LocaleJapanese: "{0}は正しいglobパターン、またはスラッシュで囲んだ正規表現でなければなりません",
+		LocaleEnglish:  "{0} must be a valid glob pattern or a regular expression enclosed in slashes",
+	},
+	"refoverlap": {
+		LocaleJapanese: "{0}は{1}と同じブランチに一致しうるため、デプロイするステージが決まりません",
+		LocaleEnglish:  "{0} can match the same branch as {1}, so the stage to deploy is ambiguous",
+	},
+	"unique": {
+		LocaleJapanese: "値が{1}と重複しています",
+		LocaleEnglish:  "value duplicates {1}",
+	},
+	"duration": {
+		LocaleJapanese: "{0}は`30s`や`1h30m`のような0以上の時間の長さでなければなりません",
+		LocaleEnglish:  "{0} must be a non-negative duration such as 30s or 1h30m",
+	},
+	"positive_duration": {
+		LocaleJapanese: "{0}は`30s`や`1h30m`のような0より大きい時間の長さでなければなりません",
+		LocaleEnglish:  "{0} must be a positive duration such as 30s or 1h30m",
+	},
+	"cron": {
+		LocaleJapanese: "{0}は`0 3 * * *`のような5フィールドのcron式、または`@hourly`や`@every 15m`のような記述子でなければなりません (`@every`の間隔は1時間または1日を割り切れる長さに限る)",
+		LocaleEnglish:  "{0} must be a five-field cron expression such as 0 3 * * * or a descriptor such as @hourly or @every 15m (the interval of @every must divide an hour or a day)",
+	},
+	"envname": {
+		LocaleJapanese: "{0}は英字またはアンダースコアで始まり、英数字とアンダースコアのみを含む環境変数名でなければなりません",
+		LocaleEnglish:  "{0} must be an environment variable name consisting of letters, digits and underscores, not starting with a digit",
+	},
+	"envvalue": {
+		LocaleJapanese: "{0}の参照は`secret://<name>/<key>`または`field://<path>`の形式でなければなりません",
+		LocaleEnglish:  "{0} must reference a secret as secret://<name>/<key> or a field as field://<path>",
+	},
+	"fieldref": {
+		LocaleJapanese: "{0}の参照先のフィールド{1}が存在しないか、参照できない値です",
+		LocaleEnglish:  "{0} refers to field {1}, which does not exist or cannot be referenced",
+	},
+	"nosecret": {
+		LocaleJapanese: "{0}にはシークレットへの参照を指定できません",
+		LocaleEnglish:  "{0} cannot reference a secret",
+	},
+	"targetport": {
+		LocaleJapanese: "{0}はservice.httpのtarget_port ({1}) のいずれかでなければなりません",
+		LocaleEnglish:  "{0} must be one of the service.http target ports ({1})",
+	},
+	"version": {
+		LocaleJapanese: "{0}は" + LatestVersion + "でなければなりません",
+		LocaleEnglish:  "{0} must be " + LatestVersion,
+	},
+	"quantity": {
+		LocaleJapanese: "{0}は`500m`や`256Mi`のような0以上、8Pi以下のリソース量でなければなりません",
+		LocaleEnglish:  "{0} must be a non-negative resource quantity up to 8Pi such as 500m or 256Mi",
+	},
+}
+
+// fieldParamRules はパラメータに構造体のフィールド名を取るルールを表す
+// パラメータは設定ファイル上のキー名に変換して報告する
+var fieldParamRules = map[string]bool{
+	"required_without":     true,
+	"required_without_all": true,
+	"excluded_with":        true,
+	"gtefield":             true,
+}
+
+// fallbackMessages は翻訳が登録されていないルールに使われるメッセージを表す
+var fallbackMessages = map[Locale]string{
+	LocaleJapanese: "{0}は{1}ルールの検証に失敗しました",
+	LocaleEnglish:  "{0} failed on the {1} rule",
+}
+
+// validation はパッケージ内で共有するバリデータと翻訳を表す
+type validation struct {
+	validate    *validator.Validate
+	translators map[Locale]ut.Translator
+}
+
+// defaultValidation はパッケージ内で共有するバリデータを返す
+// フィールド名には設定ファイル上のキー名が使われる
+var defaultValidation = sync.OnceValue(newValidation)
+
+func newValidation() *validation {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterCustomTypeFunc(quantityValue, Quantity{})
+	v.RegisterCustomTypeFunc(durationValue, Duration{})
+	_ = v.RegisterValidation("quantity", validateQuantity)
+	_ = v.RegisterValidation("refpattern", validateRefPattern)
+	_ = v.RegisterValidation("envname", validateEnvName)
+	_ = v.RegisterValidation("envvalue", validateEnvValue)
+	_ = v.RegisterValidation("nosecret", validateNoSecret)
+	_ = v.RegisterValidation("duration", validateDuration)
+	_ = v.RegisterValidation("positive_duration", validatePositiveDuration)
+	_ = v.RegisterValidation("cron", validateCron)
+	_ = v.RegisterValidation("version", validateVersion)
+	v.RegisterStructValidation(validateBuildConfig, BuildConfig{})
+	v.RegisterStructValidation(validateServiceMetricConfig, ServiceMetricConfig{})
+	v.RegisterStructValidation(validateAppConfig, AppConfig{})
+
+	uni := ut.New(ja.New(), ja.New(), en.New())
+	translators := make(map[Locale]ut.Translator, len(locales))
+	for _, l := range locales {
+		trans, _ := uni.GetTranslator(string(l))
+		translators[l] = trans
+	}
+	// 既定の翻訳は固定の内容であり、登録に失敗することはない
+	_ = ja_translations.RegisterDefaultTranslations(v, translators[LocaleJapanese])
+	_ = en_translations.RegisterDefaultTranslations(v, translators[LocaleEnglish])
+	for tag, texts := range messages {
+		for l, text := range texts {
+			registerMessage(v, translators[l], tag, text)
+		}
+	}
+	return &validation{validate: v, translators: translators}
+}
+
+func registerMessage(v *validator.Validate, trans ut.Translator, tag, text string) {
+	_ = v.RegisterTranslation(tag, trans, func(ut.Translator) error {
+		return nil
+	}, func(_ ut.Translator, fe validator.FieldError) string {
+		return strings.NewReplacer("{0}", fe.Field(), "{1}", ruleParam(fe)).Replace(text)
+	})
+}
+
+// validateStruct は s のバリデーションを行う
+// エラーがある場合は ValidationErrors を返す
+func validateStruct(s any) error {
+	return defaultValidation().toValidationErrors(defaultValidation().validate.Struct(s))
+}
+
+// ValidationError は設定ファイル上のフィールドのバリデーションエラーを表す
+type ValidationError struct {
+	// Path は設定ファイル上のパス (例: `releases[0].resources.cpu`)
+	Path string
+	// Rule は失敗したバリデーションルール (例: `required`, `min`)
+	Rule string
+	// Param はルールのパラメータ (例: `min=1` の `1`)
+	// パラメータがない場合は空
+	Param string
+	// Message は DefaultLocale で記述された利用者向けのメッセージ
+	Message string
+	// Position は設定ファイル上の位置
+	// AppConfig を直接検証した場合など、位置が不明な場合はゼロ値
+	Position Position
+
+	messages map[Locale]string
+}
+
+// LocalizedMessage は locale で記述された利用者向けのメッセージを返す
+// locale のメッセージがない場合は Message を返す
+func (e *ValidationError) LocalizedMessage(locale Locale) string {
+	if msg, ok := e.messages[locale]; ok {
+		return msg
+	}
+	return e.Message
+}
+
+func (e *ValidationError) Error() string {
+	msg := e.Path + ": " + e.Message
+	if e.Position.IsValid() {
+		return e.Position.String() + ": " + msg
+	}
+	return msg
+}
+
+// ValidationErrors はバリデーションエラーの一覧を表す
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// ruleParam は fe のルールのパラメータを返す
+// フィールド名を取るルールの場合は設定ファイル上のキー名に変換する
+func ruleParam(fe validator.FieldError) string {
+	if !fieldParamRules[fe.Tag()] {
+		return fe.Param()
+	}
+	names := strings.Fields(fe.Param())
+	for i, name := range names {
+		names[i] = snakeCase(name)
+	}
+	return strings.Join(names, " ")
+}
+
+// snakeCase は Go のフィールド名 (例: `DockerContext`) を設定ファイル上のキー名 (例: `docker_context`) に変換する
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// 略語 (例: `HTTP`) の途中では区切らない
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// mapKeyPattern はバリデータの名前空間に含まれるマップのキー (例: `env[PORT]`) を表す
+var mapKeyPattern = regexp.MustCompile(`\[([^\]]*[^0-9\]][^\]]*)\]`)
+
+// fieldPath はバリデータの名前空間から先頭の構造体名を取り除き、設定ファイル上のパスに変換する
+// マップのキーは PositionIndex と同様に `.` で連結する (例: `service.env.PORT`)
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return ""
+	}
+	return mapKeyPattern.ReplaceAllString(path, ".$1")
+}
+
+// toValidationErrors は validator のエラーを ValidationErrors に変換する
+// err がバリデーションエラーでない場合はそのまま返す
+func (v *validation) toValidationErrors(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	errs := make(ValidationErrors, len(verrs))
+	for i, fe := range verrs {
+		msgs := make(map[Locale]string, len(v.translators))
+		for l, trans := range v.translators {
+			msgs[l] = v.translate(l, trans, fe)
+		}
+		errs[i] = &ValidationError{
+			Path:     fieldPath(fe.Namespace()),
+			Rule:     fe.Tag(),
+			Param:    ruleParam(fe),
+			Message:  msgs[DefaultLocale],
+			messages: msgs,
+		}
+	}
+	return errs
+}
+
+func (v *validation) translate(l Locale, trans ut.Translator, fe validator.FieldError) string {
+	if msg := fe.Translate(trans); msg != fe.Error() {
+		return msg
+	}
+	return strings.NewReplacer("{0}", fe.Field(), "{1}", fe.Tag()).Replace(fallbackMessages[l])
+}
+
+// withPositions は err に含まれるバリデーションエラーに idx の位置情報を設定する
+func withPositions(err error, idx PositionIndex) error {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			e.Position, _ = idx.Lookup(e.Path)
+		}
+	}
+	return err
+}
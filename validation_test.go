@@ -0,0 +1,117 @@
+package apispec
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidationErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		config any
+		want   ValidationError
+		wantEn string
+	}{
+		{
+			name:   "必須フィールドが欠けている場合",
+			config: ReleaseConfig{Action: ReleaseActionConfig{Command: []string{"echo"}}, Resources: ResourceConfig{CPU: MustParseQuantity("500m"), Memory: MustParseQuantity("256Mi")}},
+			want: ValidationError{
+				Path:    "name",
+				Rule:    "required",
+				Message: "nameは必須フィールドです",
+			},
+			wantEn: "name is a required field",
+		},
+		{
+			name:   "パラメータ付きのルールに違反した場合",
+			config: ServiceHTTPConfig{TargetPort: -1},
+			want: ValidationError{
+				Path:    "target_port",
+				Rule:    "min",
+				Param:   "1",
+				Message: "target_portは1以上でなければなりません",
+			},
+			wantEn: "target_port must be 1 or greater",
+		},
+		{
+			name:   "独自のメッセージが登録されたルールに違反した場合",
+			config: BuildConfig{},
+			want: ValidationError{
+				Path:    "image",
+				Rule:    "required_without",
+				Param:   "dockerfile",
+				Message: "imageはdockerfileが指定されていない場合に必須です",
+			},
+			wantEn: "image is required when dockerfile is not set",
+		},
+		{
+			name:   "複数のフィールドを取るルールに違反した場合",
+			config: HealthcheckConfig{},
+			want: ValidationError{
+				Path:    "http",
+				Rule:    "required_without_all",
+				Param:   "process tcp grpc",
+				Message: "httpはprocess tcp grpcのいずれも指定されていない場合に必須です",
+			},
+			wantEn: "http is required when none of process tcp grpc is set",
+		},
+		{
+			name:   "フィールドの比較に違反した場合",
+			config: ServiceScaleConfig{Min: ptr(5), Max: 2, Metric: ServiceMetricConfig{Type: "cpu", Threshold: 80}},
+			want: ValidationError{
+				Path:    "max",
+				Rule:    "gtefield",
+				Param:   "min",
+				Message: "maxはmin以上でなければなりません",
+			},
+			wantEn: "max must be greater than or equal to min",
+		},
+		{
+			name:   "メトリクスの種類ごとの閾値の範囲に違反した場合",
+			config: ServiceMetricConfig{Type: "cpu", Threshold: 150},
+			want: ValidationError{
+				Path:    "threshold",
+				Rule:    "max",
+				Param:   "100",
+				Message: "thresholdは100以下でなければなりません",
+			},
+			wantEn: "threshold must be 100 or less",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var errs ValidationErrors
+			if !errors.As(validateStruct(tt.config), &errs) {
+				t.Fatalf("validateStruct() did not return ValidationErrors")
+			}
+			got := errs[0]
+			if got.Path != tt.want.Path || got.Rule != tt.want.Rule || got.Param != tt.want.Param || got.Message != tt.want.Message {
+				t.Errorf("ValidationError = %+v, want %+v", got, tt.want)
+			}
+			if msg := got.LocalizedMessage(LocaleJapanese); msg != tt.want.Message {
+				t.Errorf("LocalizedMessage(ja) = %q, want %q", msg, tt.want.Message)
+			}
+			if msg := got.LocalizedMessage(LocaleEnglish); msg != tt.wantEn {
+				t.Errorf("LocalizedMessage(en) = %q, want %q", msg, tt.wantEn)
+			}
+		})
+	}
+}
+
+func TestAppConfig_Validate_NoError(t *testing.T) {
+	t.Parallel()
+	cfg := AppConfig{
+		Version:  LatestVersion,
+		AppName:  "myapp",
+		Build:    BuildConfig{Image: "myapp:latest"},
+		Releases: []ReleaseConfig{},
+		Service:  ServiceConfig{Name: "web", Command: []string{"npm", "start"}},
+	}
+	// エラーがない場合は型付きの nil ではなく nil を返す
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
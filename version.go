@@ -0,0 +1,165 @@
+package apispec
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LatestVersion は AppConfig が表す設定ファイルの形式のバージョン
+	// 設定ファイルの `version` に記述する
+	LatestVersion = "v1"
+	// LegacyVersion は `version` を記述していない設定ファイルの形式のバージョン
+	LegacyVersion = "v0"
+)
+
+// ErrUnknownVersion は設定ファイルのバージョンが既知のものではないことを表す
+var ErrUnknownVersion = errors.New("unknown version")
+
+// versionKey はバージョンを記述するキー
+const versionKey = "version"
+
+// schemaVersion は設定ファイルの形式の1つのバージョンを表す
+type schemaVersion struct {
+	// name はバージョンの名前
+	name string
+	// typ はこのバージョンの設定ファイルを表す型
+	// strict モードの未知のキーの検出に使う
+	typ reflect.Type
+	// upgrade はこのバージョンの構文木を次のバージョンの形式に書き換える
+	// `version` の値は書き換えた後に更新されるため、upgrade で変更する必要はない
+	// 形式に変更がない場合は nil
+	upgrade func(root *yaml.Node) error
+}
+
+// versionRegistry は設定ファイルの形式のバージョンを古い順に並べたもの
+// 最後の要素は LatestVersion であり、AppConfig と同じ形式を表す
+type versionRegistry []schemaVersion
+
+// schemaVersions は読み込むことができる設定ファイルの形式のバージョン
+//
+// 形式を変更する場合は、変更前の型を残して最後の要素の typ と upgrade に設定し、
+// AppConfig を変更した上で新しいバージョンを追加する
+var schemaVersions = versionRegistry{
+	// v0 は `version` を持たないことを除いて v1 と同じ形式
+	{name: LegacyVersion, typ: reflect.TypeFor[AppConfig]()},
+	{name: LatestVersion, typ: reflect.TypeFor[AppConfig]()},
+}
+
+// Versions は読み込むことができる設定ファイルの形式のバージョンを古い順に返す
+func Versions() []string {
+	return schemaVersions.names()
+}
+
+// names はバージョンの名前を古い順に返す
+func (vs versionRegistry) names() []string {
+	names := make([]string, len(vs))
+	for i, v := range vs {
+		names[i] = v.name
+	}
+	return names
+}
+
+// lookup は name のバージョンの位置を返す
+func (vs versionRegistry) lookup(name string) (int, error) {
+	i := slices.IndexFunc(vs, func(v schemaVersion) bool { return v.name == name })
+	if i < 0 {
+		return 0, fmt.Errorf("%w %q: must be one of %s", ErrUnknownVersion, name, strings.Join(vs.names(), ", "))
+	}
+	return i, nil
+}
+
+// documentVersion は構文木に記述されたバージョンを返す
+// `version` が記述されていない場合は LegacyVersion を返す
+func documentVersion(root *yaml.Node) (string, error) {
+	for _, p := range mappingPairs(root) {
+		if p[0].Value != versionKey {
+			continue
+		}
+		if p[1].Kind != yaml.ScalarNode || p[1].ShortTag() != "!!str" {
+			return "", fmt.Errorf("%s must be a string such as %q", versionKey, LatestVersion)
+		}
+		return p[1].Value, nil
+	}
+	return LegacyVersion, nil
+}
+
+// migrate は root を version の形式から最新のバージョンの形式に書き換える
+// root は書き換えた後に `version` に最新のバージョンを持つ
+func (vs versionRegistry) migrate(root *yaml.Node, version string) error {
+	from, err := vs.lookup(version)
+	if err != nil {
+		return err
+	}
+	for i := from; i < len(vs)-1; i++ {
+		if upgrade := vs[i].upgrade; upgrade != nil {
+			if err := upgrade(root); err != nil {
+				return fmt.Errorf("failed to migrate from %s to %s: %w", vs[i].name, vs[i+1].name, err)
+			}
+		}
+		setVersion(root, vs[i+1].name)
+	}
+	return nil
+}
+
+// setVersion は root の `version` を version に設定する
+// `version` が記述されていない場合は先頭に追加し、先頭のキーのコメントを引き継ぐ
+func setVersion(root *yaml.Node, version string) {
+	for _, p := range mappingPairs(root) {
+		if p[0].Value == versionKey {
+			p[1].SetString(version)
+			return
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: versionKey}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: version}
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// validateVersion は `version` ルールを実装する
+// 値が LatestVersion であることを検証する
+func validateVersion(fl validator.FieldLevel) bool {
+	return fl.Field().String() == LatestVersion
+}
+
+// MigrateSource は data の設定ファイルを LatestVersion の形式に書き換え、FormatSource と同じ形式で出力する
+// 移行前のバージョンを合わせて返す
+// 既に LatestVersion の形式の場合は FormatSource と同じ結果になる
+//
+// 内容のバリデーションは行わない
+func MigrateSource(data []byte, format Format) ([]byte, string, error) {
+	return schemaVersions.migrateSource(data, format)
+}
+
+// migrateSource は vs に従って MigrateSource を行う
+func (vs versionRegistry) migrateSource(data []byte, format Format) ([]byte, string, error) {
+	if format == FormatAuto {
+		format = detectFormat(data)
+	}
+	doc, err := parseDocument(data, format)
+	if err != nil {
+		return nil, "", err
+	}
+	root := doc.Content[0]
+	version, err := documentVersion(root)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := vs.migrate(root, version); err != nil {
+		return nil, "", err
+	}
+	out, err := encodeNode(doc, format)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, version, nil
+}
@@ -0,0 +1,310 @@
+package apispec
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// legacyConfig は version を記述していない設定ファイル
+const legacyConfig = `# myapp の設定
+app_name: myapp
+build:
+  image: myapp:latest
+releases: []
+service:
+  name: web
+  command: [npm, start]
+`
+
+func TestLoadDocument_Version(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		input       string
+		opts        []LoadOption
+		wantVersion string
+		wantErr     error
+	}{
+		{
+			name:        "versionがない場合、v0として読み込み最新の形式に移行する",
+			input:       legacyConfig,
+			wantVersion: LegacyVersion,
+		},
+		{
+			name:        "strictの場合もversionがない設定ファイルを読み込める",
+			input:       legacyConfig,
+			opts:        []LoadOption{WithStrict()},
+			wantVersion: LegacyVersion,
+		},
+		{
+			name:        "最新のversionの場合、そのまま読み込む",
+			input:       "version: v1\n" + legacyConfig,
+			wantVersion: LatestVersion,
+		},
+		{
+			name:    "未知のversionの場合、エラーになる",
+			input:   "version: v99\n" + legacyConfig,
+			wantErr: ErrUnknownVersion,
+		},
+		{
+			name:  "versionが文字列でない場合、エラーになる",
+			input: "version: [v1]\n" + legacyConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := LoadDocument(strings.NewReader(tt.input), FormatYAML, tt.opts...)
+			if tt.wantVersion == "" {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("LoadDocument() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadDocument() error = %v", err)
+			}
+			if doc.Version != tt.wantVersion {
+				t.Errorf("Document.Version = %q, want %q", doc.Version, tt.wantVersion)
+			}
+			if doc.Config.Version != LatestVersion {
+				t.Errorf("Config.Version = %q, want %q", doc.Config.Version, LatestVersion)
+			}
+		})
+	}
+}
+
+func TestMigrateSource(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		input       string
+		format      Format
+		want        string
+		wantVersion string
+	}{
+		{
+			name:   "versionを先頭に追加し、コメントは保持される",
+			input:  legacyConfig,
+			format: FormatYAML,
+			want: `# myapp の設定
+version: v1
+app_name: myapp
+build:
+  image: myapp:latest
+releases: []
+service:
+  name: web
+  command: [npm, start]
+`,
+			wantVersion: LegacyVersion,
+		},
+		{
+			name: "空行で区切られたファイル先頭と末尾のコメントは保持される",
+			input: `# Copyright header
+
+service:
+  name: web
+app_name: myapp
+# footer
+`,
+			format: FormatYAML,
+			want: `# Copyright header
+
+version: v1
+app_name: myapp
+service:
+  name: web
+# footer
+`,
+			wantVersion: LegacyVersion,
+		},
+		{
+			name:   "JSONはJSONのまま移行される",
+			input:  `{"app_name": "myapp", "releases": []}`,
+			format: FormatAuto,
+			want: `{
+  "version": "v1",
+  "app_name": "myapp",
+  "releases": []
+}
+`,
+			wantVersion: LegacyVersion,
+		},
+		{
+			name:        "最新のversionの場合、整形のみ行う",
+			input:       "app_name: myapp\nversion: v1\n",
+			format:      FormatYAML,
+			want:        "version: v1\napp_name: myapp\n",
+			wantVersion: LatestVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, version, err := MigrateSource([]byte(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("MigrateSource() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MigrateSource() = \n%s\nwant\n%s", got, tt.want)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("MigrateSource() version = %q, want %q", version, tt.wantVersion)
+			}
+		})
+	}
+
+	t.Run("未知のversionの場合、エラーになる", func(t *testing.T) {
+		t.Parallel()
+		if _, _, err := MigrateSource([]byte("version: v2\n"), FormatYAML); !errors.Is(err, ErrUnknownVersion) {
+			t.Errorf("MigrateSource() error = %v, want ErrUnknownVersion", err)
+		}
+	})
+}
+
+func TestVersions(t *testing.T) {
+	t.Parallel()
+	versions := Versions()
+	if versions[0] != LegacyVersion || versions[len(versions)-1] != LatestVersion {
+		t.Errorf("Versions() = %v, want %s first and %s last", versions, LegacyVersion, LatestVersion)
+	}
+	// LatestVersion 以外のバージョンはバリデーションエラーになる
+	for _, v := range versions {
+		cfg, err := Load(strings.NewReader(legacyConfig), FormatYAML)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Version = v
+		if err := cfg.Validate(); (err == nil) != (v == LatestVersion) {
+			t.Errorf("Validate() with version %s error = %v", v, err)
+		}
+	}
+}
+
+// upgradeRuntime は `runtime` を `service` に改名し、トップレベルの `image` を `build.image` に移動する
+// 形式を変更するバージョンの移行を模したもの
+func upgradeRuntime(root *yaml.Node) error {
+	var pairs [][2]*yaml.Node
+	var image [2]*yaml.Node
+	for _, p := range mappingPairs(root) {
+		switch p[0].Value {
+		case "runtime":
+			p[0].Value = "service"
+		case "image":
+			image = p
+			continue
+		}
+		pairs = append(pairs, p)
+	}
+	if image[0] == nil {
+		return errors.New("image is missing")
+	}
+	build := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: image[:]}
+	pairs = append(pairs, [2]*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: "build"}, build})
+	setMappingPairs(root, pairs)
+	return nil
+}
+
+// runtimeVersions は upgradeRuntime で移行する v0 を持つバージョンの一覧
+var runtimeVersions = versionRegistry{
+	{name: LegacyVersion, typ: reflect.TypeFor[AppConfig](), upgrade: upgradeRuntime},
+	{name: LatestVersion, typ: reflect.TypeFor[AppConfig]()},
+}
+
+// runtimeConfig は runtimeVersions の v0 の形式の設定ファイル
+const runtimeConfig = `# myapp の設定
+app_name: myapp
+image: myapp:latest
+releases: []
+runtime:
+  name: web
+  command: [npm, start]
+  http:
+    - target_port: 0
+`
+
+func TestMigrate_Upgrade(t *testing.T) {
+	t.Parallel()
+
+	t.Run("MigrateSourceは形式を書き換える", func(t *testing.T) {
+		t.Parallel()
+		got, version, err := runtimeVersions.migrateSource([]byte(runtimeConfig), FormatYAML)
+		if err != nil {
+			t.Fatalf("migrateSource() error = %v", err)
+		}
+		want := `# myapp の設定
+version: v1
+app_name: myapp
+build:
+  image: myapp:latest
+releases: []
+service:
+  name: web
+  command: [npm, start]
+  http:
+    - target_port: 0
+`
+		if string(got) != want {
+			t.Errorf("migrateSource() = \n%s\nwant\n%s", got, want)
+		}
+		if version != LegacyVersion {
+			t.Errorf("migrateSource() version = %q, want %q", version, LegacyVersion)
+		}
+	})
+
+	t.Run("移行失敗の場合、バージョンを含むエラーになる", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := runtimeVersions.migrateSource([]byte("app_name: myapp\n"), FormatYAML)
+		if err == nil || !strings.Contains(err.Error(), "failed to migrate from v0 to v1") {
+			t.Errorf("migrateSource() error = %v, want a migration error", err)
+		}
+	})
+
+	t.Run("LoadDocumentは移行後の設定を読み込む", func(t *testing.T) {
+		t.Parallel()
+		input := strings.Replace(runtimeConfig, "target_port: 0", "target_port: 8080", 1)
+		doc, err := LoadDocument(strings.NewReader(input), FormatYAML, withVersions(runtimeVersions))
+		if err != nil {
+			t.Fatalf("LoadDocument() error = %v", err)
+		}
+		if doc.Version != LegacyVersion {
+			t.Errorf("Document.Version = %q, want %q", doc.Version, LegacyVersion)
+		}
+		if doc.Config.Build.Image != "myapp:latest" || doc.Config.Service.Name != "web" {
+			t.Errorf("Config = %+v, want the migrated build and service", doc.Config)
+		}
+		// 移行後のパスから移行前の位置を引ける
+		if got, want := doc.Positions["build.image"], (Position{Line: 3, Column: 1}); got != want {
+			t.Errorf("Positions[build.image] = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("バリデーションエラーは移行前の位置を指す", func(t *testing.T) {
+		t.Parallel()
+		_, err := LoadDocument(strings.NewReader(runtimeConfig), FormatYAML, withVersions(runtimeVersions))
+		var errs ValidationErrors
+		if !errors.As(err, &errs) || len(errs) != 1 {
+			t.Fatalf("LoadDocument() error = %v, want one validation error", err)
+		}
+		if got, want := errs[0].Path, "service.http[0].target_port"; got != want {
+			t.Errorf("Path = %q, want %q", got, want)
+		}
+		if got, want := errs[0].Position, (Position{Line: 9, Column: 7}); got != want {
+			t.Errorf("Position = %v, want %v", got, want)
+		}
+	})
+}
+
+// withVersions は読み込むことができるバージョンを vs に置き換える
+func withVersions(vs versionRegistry) LoadOption {
+	return func(o *loadOptions) {
+		o.versions = vs
+	}
+}